@@ -13,7 +13,7 @@ const Account3UUIDPlaceholder = "00000000-0000-0000-0000-000000000000"
 type AutomationAction interface {
 	TypeID() string
 	Parameters() []*string
-	Init(ctx context.Context, env *Environment)
+	Init(ctx context.Context, env *Environment) error
 	IsSatisfied() bool
 	Requires3() bool
 	AllowGlobalOptimization() bool
@@ -7,10 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
-	keyhub "github.com/topicuskeyhub/sdk-go"
 	keyhubaccount "github.com/topicuskeyhub/sdk-go/account"
 	"github.com/topicuskeyhub/sdk-go/models"
 	keyhubvaultrecord "github.com/topicuskeyhub/sdk-go/vaultrecord"
@@ -22,6 +20,12 @@ type AuthenticationConfig struct {
 	ClientSecret            string
 	Scopes                  []string
 	VaultRecoveryRecordUUID string
+	// Authenticators authenticate account 1, 2 and 3, in that order. Accounts without an
+	// authenticator log in with the device authorization flow.
+	Authenticators []Authenticator
+	// TokenCache, when set, is used for the device authorization flow to reuse the tokens of
+	// previous runs.
+	TokenCache *TokenCache
 }
 
 func NewAuthenticationConfig(issuer string, clientID string, clientSecret string) AuthenticationConfig {
@@ -42,28 +46,27 @@ func NewAuthenticationConfig(issuer string, clientID string, clientSecret string
 	}
 }
 
-func authenticateWithDeviceFlow(ctx context.Context, config AuthenticationConfig) (*AuthenticatedAccount, error) {
-	adapter, err := keyhub.NewKeyHubRequestAdapterForDeviceCode(&http.Client{}, config.Issuer, config.ClientID, config.ClientSecret, config.Scopes)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create Topicus KeyHub API client: %s", err)
+func (c AuthenticationConfig) authenticator(index int) Authenticator {
+	if index < len(c.Authenticators) && c.Authenticators[index] != nil {
+		return c.Authenticators[index]
 	}
-
-	client := keyhub.NewKeyHubClient(adapter)
-	account, err := client.Account().Me().Get(ctx, nil)
-
-	ret := &AuthenticatedAccount{
-		Client:  client,
-		Account: account,
+	if c.TokenCache != nil {
+		return c.TokenCache.Authenticator(c, index)
 	}
+	return NewDeviceFlowAuthenticator(c)
+}
 
+// authenticate authenticates the account with the given index and checks that it is fit to
+// execute actions.
+func authenticate(ctx context.Context, config AuthenticationConfig, index int) (*AuthenticatedAccount, error) {
+	ret, err := config.authenticator(index).Authenticate(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("unable to fetch account: %s", KeyHubError(err))
+		return nil, err
 	}
 	err = checkKeyHubAdmin(ctx, ret)
 	if err != nil {
 		return nil, fmt.Errorf("user fails sanity checks: %s", err)
 	}
-
 	return ret, nil
 }
 
@@ -76,7 +79,11 @@ func checkKeyHubAdmin(ctx context.Context, account *AuthenticatedAccount) error
 		return errors.New("user is not a Topicus KeyHub Administrator")
 	}
 
-	ownAccount, err := account.Client.Account().ByAccountidInt64(*Self(account.Account).GetId()).Get(ctx, &keyhubaccount.WithAccountItemRequestBuilderGetRequestConfiguration{
+	accountID, err := SelfID(account.Account)
+	if err != nil {
+		return fmt.Errorf("unable to determine id of own account: %s", err)
+	}
+	ownAccount, err := account.Client.Account().ByAccountidInt64(accountID).Get(ctx, &keyhubaccount.WithAccountItemRequestBuilderGetRequestConfiguration{
 		QueryParameters: &keyhubaccount.WithAccountItemRequestBuilderGetQueryParameters{
 			Additional: []string{"groups"},
 		},
@@ -97,12 +104,12 @@ func checkKeyHubAdmin(ctx context.Context, account *AuthenticatedAccount) error
 }
 
 func SetupEnvironment(ctx context.Context, config AuthenticationConfig) (*Environment, error) {
-	account1, err := authenticateWithDeviceFlow(ctx, config)
+	account1, err := authenticate(ctx, config, 0)
 	if err != nil {
 		return nil, fmt.Errorf("unable to authenticate first user: %s", err)
 	}
 
-	account2, err := authenticateWithDeviceFlow(ctx, config)
+	account2, err := authenticate(ctx, config, 1)
 	if err != nil {
 		return nil, fmt.Errorf("unable to authenticate second user: %s", err)
 	}
@@ -114,6 +121,7 @@ func SetupEnvironment(ctx context.Context, config AuthenticationConfig) (*Enviro
 	ret := &Environment{
 		Account1: account1,
 		Account2: account2,
+		Cache:    NewCache(),
 	}
 
 	if config.VaultRecoveryRecordUUID != "" {
@@ -132,7 +140,7 @@ func SetupEnvironment(ctx context.Context, config AuthenticationConfig) (*Enviro
 }
 
 func AuthenticateAccount3(ctx context.Context, config AuthenticationConfig, env *Environment) error {
-	account3, err := authenticateWithDeviceFlow(ctx, config)
+	account3, err := authenticate(ctx, config, 2)
 	if err != nil {
 		return fmt.Errorf("unable to authenticate third user: %s", err)
 	}
@@ -0,0 +1,223 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	abs "github.com/microsoft/kiota-abstractions-go"
+	"github.com/microsoft/kiota-abstractions-go/authentication"
+	nethttplibrary "github.com/microsoft/kiota-http-go"
+	keyhub "github.com/topicuskeyhub/sdk-go"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Authenticator authenticates a single account to Topicus KeyHub.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (*AuthenticatedAccount, error)
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(ctx context.Context) (*AuthenticatedAccount, error)
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context) (*AuthenticatedAccount, error) {
+	return f(ctx)
+}
+
+func newAuthenticatedAccount(ctx context.Context, adapter abs.RequestAdapter) (*AuthenticatedAccount, error) {
+	client := keyhub.NewKeyHubClient(adapter)
+	account, err := client.Account().Me().Get(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch account: %s", KeyHubError(err))
+	}
+	return &AuthenticatedAccount{
+		Client:  client,
+		Account: account,
+	}, nil
+}
+
+// NewDeviceFlowAuthenticator returns an authenticator that asks the user to log in with the OAuth2
+// device authorization flow.
+func NewDeviceFlowAuthenticator(config AuthenticationConfig) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context) (*AuthenticatedAccount, error) {
+		adapter, err := keyhub.NewKeyHubRequestAdapterForDeviceCode(&http.Client{}, config.Issuer, config.ClientID, config.ClientSecret, config.Scopes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Topicus KeyHub API client: %s", err)
+		}
+		return newAuthenticatedAccount(ctx, adapter)
+	})
+}
+
+// NewClientCredentialsAuthenticator returns an authenticator that logs in with the OAuth2 client
+// credentials grant, without user interaction. As every account must be a KeyHub administrator,
+// the tokens issued to the client must identify such an account.
+func NewClientCredentialsAuthenticator(config AuthenticationConfig, clientID string, clientSecret string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context) (*AuthenticatedAccount, error) {
+		oauthConfig, err := oauth2Config(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		credentials := &clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     oauthConfig.Endpoint.TokenURL,
+			Scopes:       config.Scopes,
+		}
+		return NewHTTPClientAuthenticator(credentials.Client(ctx), apiURL(config)).Authenticate(ctx)
+	})
+}
+
+// NewHTTPClientAuthenticator returns an authenticator that uses the given client, which must
+// already add credentials to its requests, to call the API at the given base URL, for example
+// https://keyhub.example.com/keyhub/rest/v1.
+func NewHTTPClientAuthenticator(httpClient *http.Client, baseURL string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context) (*AuthenticatedAccount, error) {
+		adapter, err := nethttplibrary.NewNetHttpRequestAdapterWithParseNodeFactoryAndSerializationWriterFactoryAndHttpClient(
+			&authentication.AnonymousAuthenticationProvider{}, nil, nil, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Topicus KeyHub API client: %s", err)
+		}
+		adapter.SetBaseUrl(baseURL)
+		return newAuthenticatedAccount(ctx, adapter)
+	})
+}
+
+// NewAdapterAuthenticator returns an authenticator that uses a preconfigured request adapter.
+func NewAdapterAuthenticator(adapter abs.RequestAdapter) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context) (*AuthenticatedAccount, error) {
+		return newAuthenticatedAccount(ctx, adapter)
+	})
+}
+
+// providerMetadata holds the endpoints published in the OpenID Connect discovery document of an
+// issuer.
+type providerMetadata struct {
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+var discovered sync.Map
+
+// discover reads the discovery document of the issuer. The result is remembered, so the document is
+// fetched only once per run.
+func discover(ctx context.Context, issuer string) (*providerMetadata, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if metadata, ok := discovered.Load(issuer); ok {
+		return metadata.(*providerMetadata), nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := oauth2.NewClient(ctx, nil).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover the endpoints of %s: %s", issuer, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to discover the endpoints of %s: %s", issuer, resp.Status)
+	}
+	var metadata providerMetadata
+	err = json.NewDecoder(resp.Body).Decode(&metadata)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery document of %s: %s", issuer, err)
+	}
+	if metadata.TokenEndpoint == "" {
+		return nil, fmt.Errorf("discovery document of %s has no token endpoint", issuer)
+	}
+	discovered.Store(issuer, &metadata)
+	return &metadata, nil
+}
+
+func oauth2Config(ctx context.Context, config AuthenticationConfig) (*oauth2.Config, error) {
+	metadata, err := discover(ctx, config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Scopes:       config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:       metadata.AuthorizationEndpoint,
+			TokenURL:      metadata.TokenEndpoint,
+			DeviceAuthURL: metadata.DeviceAuthorizationEndpoint,
+		},
+	}, nil
+}
+
+func apiURL(config AuthenticationConfig) string {
+	return strings.TrimSuffix(config.Issuer, "/") + "/keyhub/rest/v1"
+}
+
+// NewRefreshTokenAuthenticator returns an authenticator that uses the refresh token stored in the
+// file to obtain access tokens. When KeyHub issues a new refresh token, it is written back to the
+// file.
+func NewRefreshTokenAuthenticator(config AuthenticationConfig, path string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context) (*AuthenticatedAccount, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read refresh token: %s", err)
+		}
+		refreshToken := strings.TrimSpace(string(content))
+		if refreshToken == "" {
+			return nil, fmt.Errorf("no refresh token in %s", path)
+		}
+		oauthConfig, err := oauth2Config(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		source := newPersistingTokenSource(oauthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}),
+			func(token *oauth2.Token) error {
+				if token.RefreshToken == "" || token.RefreshToken == refreshToken {
+					return nil
+				}
+				refreshToken = token.RefreshToken
+				return os.WriteFile(path, []byte(refreshToken+"\n"), 0600)
+			})
+		httpClient := oauth2.NewClient(ctx, source)
+		return NewHTTPClientAuthenticator(httpClient, apiURL(config)).Authenticate(ctx)
+	})
+}
+
+// persistingTokenSource passes every newly issued token to a store function, to persist it.
+type persistingTokenSource struct {
+	source      oauth2.TokenSource
+	store       func(token *oauth2.Token) error
+	mu          sync.Mutex
+	accessToken string
+}
+
+func newPersistingTokenSource(source oauth2.TokenSource, store func(token *oauth2.Token) error) *persistingTokenSource {
+	return &persistingTokenSource{
+		source: source,
+		store:  store,
+	}
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token.AccessToken != s.accessToken {
+		err = s.store(token)
+		if err != nil {
+			return nil, fmt.Errorf("unable to store token: %s", err)
+		}
+		s.accessToken = token.AccessToken
+	}
+	return token, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/keyhubtest"
+)
+
+func TestRefreshTokenAuthenticator(t *testing.T) {
+	server := keyhubtest.NewServer()
+	defer server.Close()
+	admin := server.AddAdministrator("admin1")
+
+	path := filepath.Join(t.TempDir(), "admin1.token")
+	initial := server.RefreshToken(admin)
+	err := os.WriteFile(path, []byte(initial+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := server.Config()
+	account, err := action.NewRefreshTokenAuthenticator(config, path).Authenticate(context.Background())
+	if err != nil {
+		t.Fatalf("authenticate: %s", err)
+	}
+	if *account.Account.GetUuid() != admin.UUID {
+		t.Errorf("authenticated as %s, want %s", *account.Account.GetUsername(), admin.Username)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := strings.TrimSpace(string(content))
+	if stored == initial || stored == "" {
+		t.Errorf("refresh token not rotated in %s: %q", path, stored)
+	}
+}
+
+func TestRefreshTokenAuthenticatorInvalidToken(t *testing.T) {
+	server := keyhubtest.NewServer()
+	defer server.Close()
+	server.AddAdministrator("admin1")
+
+	path := filepath.Join(t.TempDir(), "admin1.token")
+	err := os.WriteFile(path, []byte("refresh-unknown\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = action.NewRefreshTokenAuthenticator(server.Config(), path).Authenticate(context.Background())
+	if err == nil {
+		t.Fatal("expected an error for an unknown refresh token")
+	}
+}
+
+func TestClientCredentialsAuthenticator(t *testing.T) {
+	server := keyhubtest.NewServer()
+	defer server.Close()
+	admin := server.AddAdministrator("admin1")
+	clientID, secret := server.ClientCredentials(admin)
+
+	tests := []struct {
+		name    string
+		secret  string
+		success bool
+	}{
+		{"valid secret", secret, true},
+		{"invalid secret", "secret-unknown", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := action.NewClientCredentialsAuthenticator(server.Config(), clientID, tt.secret).Authenticate(context.Background())
+			if !tt.success {
+				if err == nil {
+					t.Fatal("expected an error for an invalid client secret")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %s", err)
+			}
+			if *account.Account.GetUuid() != admin.UUID {
+				t.Errorf("authenticated as %s, want %s", *account.Account.GetUsername(), admin.Username)
+			}
+		})
+	}
+}
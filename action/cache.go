@@ -0,0 +1,85 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"slices"
+	"sync"
+)
+
+// Cache holds the resources read during a single run, keyed by resource type and UUID, so actions
+// collected for the same groups and accounts do not read them over and over again. After every
+// executed action, the runner invalidates the entries of the resources the action touches, or the
+// entire cache when the action does not declare these.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]any
+}
+
+type cacheKey struct {
+	kind string
+	key  string
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		entries: make(map[cacheKey]any),
+	}
+}
+
+// Invalidate removes all entries from the cache. It is safe to call on a nil cache.
+func (c *Cache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]any)
+}
+
+// InvalidateKeys removes the entries of every kind with one of the given keys. It is safe to call
+// on a nil cache.
+func (c *Cache) InvalidateKeys(keys []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		if slices.Contains(keys, k.key) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *Cache) get(kind string, key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret, ok := c.entries[cacheKey{kind, key}]
+	return ret, ok
+}
+
+// Put stores the value for the kind of resource and key, for example when it was read in bulk.
+func (c *Cache) Put(kind string, key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[cacheKey{kind, key}] = value
+}
+
+// Cached returns the cached value for the kind of resource and key, or loads and caches it. Errors
+// are not cached. Without a cache in the environment, the value is always loaded.
+func Cached[T any](env *Environment, kind string, key string, load func() (T, error)) (T, error) {
+	if env.Cache == nil {
+		return load()
+	}
+	if ret, ok := env.Cache.get(kind, key); ok {
+		return ret.(T), nil
+	}
+	ret, err := load()
+	if err != nil {
+		return ret, err
+	}
+	env.Cache.Put(kind, key, ret)
+	return ret, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action_test
+
+import (
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+)
+
+func TestCached(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(cache *action.Cache)
+		loads      int
+	}{
+		{"cached", func(cache *action.Cache) {}, 1},
+		{"invalidated", func(cache *action.Cache) { cache.Invalidate() }, 2},
+		{"key invalidated", func(cache *action.Cache) { cache.InvalidateKeys([]string{"uuid-1"}) }, 2},
+		{"other key invalidated", func(cache *action.Cache) { cache.InvalidateKeys([]string{"uuid-2"}) }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &action.Environment{Cache: action.NewCache()}
+			loads := 0
+			load := func() (string, error) {
+				loads++
+				return "value", nil
+			}
+			for i := 0; i < 2; i++ {
+				value, err := action.Cached(env, "group", "uuid-1", load)
+				if err != nil || value != "value" {
+					t.Fatalf("cached value %q, %v", value, err)
+				}
+				if i == 0 {
+					tt.invalidate(env.Cache)
+				}
+			}
+			if loads != tt.loads {
+				t.Errorf("loaded %d times, want %d", loads, tt.loads)
+			}
+		})
+	}
+}
@@ -6,8 +6,8 @@ package action
 import (
 	"context"
 	"fmt"
-	"os"
 	"slices"
+	"strings"
 )
 
 type Stepper interface {
@@ -16,18 +16,89 @@ type Stepper interface {
 	Done()
 }
 
-func Collect(ctx context.Context, action AutomationAction, env *Environment, stepper Stepper) []AutomationAction {
-	var err error
-	action.Init(ctx, env)
+func Collect(ctx context.Context, action AutomationAction, env *Environment, stepper Stepper) ([]AutomationAction, error) {
+	actions, _, err := CollectTree(ctx, action, env, stepper, CollectOptions{})
+	return actions, err
+}
+
+// CollectTree collects the actions like Collect and also returns the tree of plan nodes, which
+// records for every step the action it was collected for and its role.
+func CollectTree(ctx context.Context, action AutomationAction, env *Environment, stepper Stepper, options CollectOptions) ([]AutomationAction, *PlanNode, error) {
+	c := &collector{ctx: ctx, env: env, stepper: stepper, options: options}
+	return c.collect(action)
+}
+
+// collector holds the state shared by all steps of a single collection. When model is set, every
+// initialized action that implements Simulated records its observations in it.
+type collector struct {
+	ctx     context.Context
+	env     *Environment
+	stepper Stepper
+	model   *Model
+	options CollectOptions
+	root    *PlanNode
+	steps   []*PlanNode
+}
+
+func (c *collector) collect(action AutomationAction) ([]AutomationAction, *PlanNode, error) {
+	err := Prefetch(c.ctx, action, c.env)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = c.init(action)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s\n  at %s", err, action.String())
+	}
 	ret := make([]AutomationAction, 0)
-	ret, err = traverse(ctx, 1, action, false, env, stepper, ret)
+	ret, err = c.traverse(1, action, false, nil, RoleMain, ret)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
-		return nil
+		return nil, nil, err
 	}
-	ret = deleteInverses(ret)
-	return ret
+	ret = deleteInverses(ret, c.linkInverses)
+	if c.root == nil {
+		c.root = newPlanNode(action, RoleMain, nil)
+	}
+	c.index(ret)
+	return ret, c.root, nil
+}
+
+// linkInverses records on the nodes of both actions that they were eliminated together.
+func (c *collector) linkInverses(a AutomationAction, b AutomationAction) {
+	var nodeA, nodeB *PlanNode
+	for _, node := range c.steps {
+		if node.Action == a {
+			nodeA = node
+		} else if node.Action == b {
+			nodeB = node
+		}
+	}
+	if nodeA != nil && nodeB != nil {
+		nodeA.Inverse = nodeB
+		nodeB.Inverse = nodeA
+	}
+}
+
+// index assigns the positions in the list of actions, which retains the order of the collected
+// steps, to their nodes. Steps eliminated by deleteInverses keep index -1.
+func (c *collector) index(actions []AutomationAction) {
+	i := 0
+	for _, node := range c.steps {
+		if i < len(actions) && actions[i] == node.Action {
+			node.Index = i
+			i++
+		}
+	}
+}
+
+func (c *collector) init(action AutomationAction) error {
+	err := action.Init(c.ctx, c.env)
+	if err != nil {
+		return err
+	}
+	if simulated, ok := action.(Simulated); ok && c.model != nil {
+		simulated.Observe(c.model)
+	}
+	return nil
 }
 
 func addSteps(stepper Stepper, steps []AutomationAction) []AutomationAction {
@@ -42,45 +113,64 @@ func addStep(stepper Stepper, step AutomationAction) AutomationAction {
 	return step
 }
 
-func traverse(ctx context.Context, depth int, action AutomationAction, force bool, env *Environment, stepper Stepper, result []AutomationAction) ([]AutomationAction, error) {
+func (c *collector) traverse(depth int, action AutomationAction, force bool, parent *PlanNode, role PlanRole, result []AutomationAction) ([]AutomationAction, error) {
 	if !force && action.IsSatisfied() {
 		return result, nil
 	}
-	if depth > 20 {
-		return nil, fmt.Errorf("maximum depth of 20 exceeded:\n  at %s", action.String())
+	if depth > c.options.maxDepth() {
+		return nil, fmt.Errorf("maximum depth of %d exceeded:\n  at %s", c.options.maxDepth(), action.String())
+	}
+	if cycle := findCycle(action, role, parent); cycle != "" {
+		return nil, fmt.Errorf("cycle detected, %s requires itself:%s", action.String(), cycle)
+	}
+	node := newPlanNode(action, role, parent)
+	if parent == nil {
+		c.root = node
 	}
 
 	var err error
 	ret := result
 	cleanup := make([]AutomationAction, 0)
-	for _, a := range addSteps(stepper, action.Setup(env)) {
-		stepper.Step()
-		a.Init(ctx, env)
+	for _, a := range addSteps(c.stepper, action.Setup(c.env)) {
+		c.stepper.Step()
+		err = c.init(a)
+		if err != nil {
+			return nil, fmt.Errorf("%s\n  at %s\n  at %s", err, a.String(), action.String())
+		}
 		if !a.IsSatisfied() {
-			ret, err = traverse(ctx, depth+1, a, false, env, stepper, ret)
+			ret, err = c.traverse(depth+1, a, false, node, RoleSetup, ret)
 			if err != nil {
 				return nil, fmt.Errorf("%s\n  at %s", err, action.String())
 			}
-			revert := addStep(stepper, a.Revert())
+			revert := addStep(c.stepper, a.Revert())
 			if revert != nil {
 				cleanup = append(cleanup, revert)
 			}
 		}
 	}
-	ret = append(ret, action)
-	for _, a := range addSteps(stepper, action.Perform(env)) {
-		stepper.Step()
-		a.Init(ctx, env)
-		ret, err = traverse(ctx, depth+1, a, force, env, stepper, ret)
+	if !isComposite(action) {
+		ret = append(ret, action)
+		c.steps = append(c.steps, node)
+	}
+	for _, a := range addSteps(c.stepper, action.Perform(c.env)) {
+		c.stepper.Step()
+		err = c.init(a)
+		if err != nil {
+			return nil, fmt.Errorf("%s\n  at %s\n  at %s", err, a.String(), action.String())
+		}
+		ret, err = c.traverse(depth+1, a, force, node, RolePerform, ret)
 		if err != nil {
 			return nil, fmt.Errorf("%s\n  at %s", err, action.String())
 		}
 	}
 	slices.Reverse(cleanup)
 	for _, a := range cleanup {
-		stepper.Step()
-		a.Init(ctx, env)
-		ret, err = traverse(ctx, depth+1, a, true, env, stepper, ret)
+		c.stepper.Step()
+		err = c.init(a)
+		if err != nil {
+			return nil, fmt.Errorf("%s\n  at %s\n  at %s", err, a.String(), action.String())
+		}
+		ret, err = c.traverse(depth+1, a, true, node, RoleCleanup, ret)
 		if err != nil {
 			return nil, fmt.Errorf("%s\n  at %s", err, action.String())
 		}
@@ -88,12 +178,29 @@ func traverse(ctx context.Context, depth int, action AutomationAction, force boo
 	return ret, nil
 }
 
-func deleteInverses(actions []AutomationAction) []AutomationAction {
+// findCycle returns the chain from the action up to the nearest ancestor equal to it, in the format
+// of PlanNode.Cause, or an empty string when no ancestor is equal to the action.
+func findCycle(action AutomationAction, role PlanRole, parent *PlanNode) string {
+	var b strings.Builder
+	for p := parent; p != nil; p = p.Parent {
+		fmt.Fprintf(&b, "\n  %s of %s", role, p.Action.String())
+		if IsEqual(p.Action, action) {
+			return b.String()
+		}
+		role = p.Role
+	}
+	return ""
+}
+
+// deleteInverses removes the pairs of actions that cancel each other out, reporting every removed
+// pair to the callback.
+func deleteInverses(actions []AutomationAction, removed func(a AutomationAction, b AutomationAction)) []AutomationAction {
 	ret := actions
 	for i1 := 0; i1 < len(ret)-1; i1++ {
 		if ret[i1].AllowGlobalOptimization() {
 			for i2 := i1 + 1; i2 < len(ret); i2++ {
 				if IsInverse(ret[i1], ret[i2]) {
+					removed(ret[i1], ret[i2])
 					ret = slices.Delete(ret, i2, i2+1)
 					ret = slices.Delete(ret, i1, i1+1)
 					i1 = i1 - 2
@@ -105,6 +212,7 @@ func deleteInverses(actions []AutomationAction) []AutomationAction {
 			}
 		} else {
 			if IsInverse(ret[i1], ret[i1+1]) {
+				removed(ret[i1], ret[i1+1])
 				ret = slices.Delete(ret, i1, i1+2)
 				i1 = i1 - 2
 				if i1 < -1 {
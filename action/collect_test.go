@@ -0,0 +1,101 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/automation-framework/keyhubtest"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// newAddUser returns an environment on a fake KeyHub and an action adding a new account to a new
+// group as a normal member, which takes a setup and a cleanup step.
+func newAddUser(t *testing.T) (*keyhubtest.Server, *action.Environment, action.AutomationAction) {
+	server := keyhubtest.NewServer()
+	t.Cleanup(server.Close)
+	env, err := server.Environment(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("setup environment: %s", err)
+	}
+	user := server.AddAccount("user")
+	team := server.AddGroup("Team")
+	return server, env, actions.NewAccountInGroup(user.UUID, team.UUID, action.Ptr(models.NORMAL_GROUPGROUPRIGHTS))
+}
+
+func TestCollectTree(t *testing.T) {
+	_, env, a := newAddUser(t)
+	steps, tree, err := action.CollectTree(context.Background(), a, env, nopStepper{}, action.CollectOptions{})
+	if err != nil {
+		t.Fatalf("collect: %s", err)
+	}
+	if tree.Action != a || tree.Role != action.RoleMain {
+		t.Fatalf("root of the tree is %s %s, want main %s", tree.Role, tree.Action.String(), a.String())
+	}
+	tests := []struct {
+		step string
+		role action.PlanRole
+	}{
+		{"Add admin1 to 'Team' as manager", action.RoleSetup},
+		{"Add user to 'Team' as normal member", action.RoleMain},
+		{"Remove admin1 from 'Team'", action.RoleCleanup},
+	}
+	nodes := tree.Steps()
+	if len(steps) != len(tests) || len(nodes) != len(tests) {
+		t.Fatalf("collected %d steps and %d nodes, want %d", len(steps), len(nodes), len(tests))
+	}
+	for i, tt := range tests {
+		if nodes[i].Action != steps[i] {
+			t.Errorf("node %d is %s, want %s", i, nodes[i].Action.String(), steps[i].String())
+		}
+		if steps[i].String() != tt.step || nodes[i].Role != tt.role {
+			t.Errorf("step %d is %s %s, want %s %s", i, nodes[i].Role, steps[i].String(), tt.role, tt.step)
+		}
+	}
+}
+
+func TestCollectMaxDepth(t *testing.T) {
+	tests := []struct {
+		maxDepth int
+		err      string
+	}{
+		{0, ""},
+		{2, ""},
+		{1, "maximum depth of 1 exceeded"},
+	}
+	for _, tt := range tests {
+		_, env, a := newAddUser(t)
+		_, _, err := action.CollectTree(context.Background(), a, env, nopStepper{}, action.CollectOptions{MaxDepth: tt.maxDepth})
+		if tt.err == "" && err != nil {
+			t.Errorf("max depth %d: %s", tt.maxDepth, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("max depth %d: error %v, want %s", tt.maxDepth, err, tt.err)
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	server, env, a := newAddUser(t)
+	simulation, err := action.Simulate(context.Background(), a, env, nopStepper{}, action.CollectOptions{})
+	if err != nil {
+		t.Fatalf("simulate: %s", err)
+	}
+	if len(simulation.Failures) > 0 {
+		t.Fatalf("simulation failed at %s: %s", simulation.Failures[0].Action.String(), simulation.Failures[0].Err)
+	}
+	want := []string{"+ user is member of Team"}
+	diff := simulation.Final.Diff(simulation.Initial)
+	if !slices.Equal(diff, want) {
+		t.Errorf("simulated changes %q, want %q", diff, want)
+	}
+	if len(server.Requests()) > 0 {
+		t.Errorf("the simulation submitted requests to KeyHub")
+	}
+}
@@ -18,4 +18,6 @@ type Environment struct {
 	Account2         *AuthenticatedAccount
 	Account3         *AuthenticatedAccount
 	VaultRecoveryKey string
+	// Cache holds the resources read during this run, it may be nil to disable caching.
+	Cache *Cache
 }
@@ -13,13 +13,16 @@ import (
 	"github.com/ttacon/chalk"
 )
 
+// ErrNoRecords is returned by First when the result is empty.
+var ErrNoRecords = errors.New("no records found")
+
 func First[T models.Linkableable](wrapper interface{ GetItems() []T }, err error) (T, error) {
 	var ret T
 	if err != nil {
 		return ret, err
 	}
 	if len(wrapper.GetItems()) == 0 {
-		return ret, fmt.Errorf("no records found")
+		return ret, ErrNoRecords
 	}
 	return wrapper.GetItems()[0], nil
 }
@@ -28,33 +31,48 @@ func Ptr[T any](val T) *T {
 	return &val
 }
 
-func Self(linkable models.Linkableable) models.RestLinkable {
+func Self(linkable models.Linkableable) (models.RestLinkable, error) {
 	for _, l := range linkable.GetLinks() {
 		if *l.GetRel() == "self" {
-			return l
+			return l, nil
 		}
 	}
-	Abort(nil, "item does not have a self link")
-	return nil
+	return nil, errors.New("item does not have a self link")
+}
+
+// SelfID returns the id of the self link of the item.
+func SelfID(linkable models.Linkableable) (int64, error) {
+	self, err := Self(linkable)
+	if err != nil {
+		return 0, err
+	}
+	return *self.GetId(), nil
 }
 
-func Koppeling(linkable models.Linkableable) models.RestLinkable {
+func Koppeling(linkable models.Linkableable) (models.RestLinkable, error) {
 	for _, l := range linkable.GetLinks() {
 		if *l.GetRel() == "koppeling" {
-			return l
+			return l, nil
 		}
 	}
-	Abort(nil, "item does not have a koppeling link")
-	return nil
+	return nil, errors.New("item does not have a koppeling link")
+}
+
+// KoppelingID returns the id of the koppeling link of the item.
+func KoppelingID(linkable models.Linkableable) (int64, error) {
+	koppeling, err := Koppeling(linkable)
+	if err != nil {
+		return 0, err
+	}
+	return *koppeling.GetId(), nil
 }
 
-func Abort(action AutomationAction, format string, v ...any) {
+func printError(action AutomationAction, format string, v ...any) {
 	if action == nil {
 		fmt.Fprintf(os.Stderr, "\n\n%serror: %s%s\n", chalk.Red, fmt.Sprintf(format, v...), chalk.Reset)
 	} else {
 		fmt.Fprintf(os.Stderr, "\n\n%serror in %s: %s%s\n", chalk.Red, action.String(), fmt.Sprintf(format, v...), chalk.Reset)
 	}
-	os.Exit(1)
 }
 
 func KeyHubError(err error) error {
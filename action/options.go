@@ -0,0 +1,90 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"fmt"
+	"time"
+)
+
+// Exit codes returned by RunWithOptions and RunPlanWithOptions. Exit code 2 is left to commands
+// to report invalid usage.
+const (
+	ExitSuccess = 0
+	ExitFailure = 1
+	ExitSkipped = 3
+)
+
+// FailurePolicy determines what happens when the execution of an action fails.
+type FailurePolicy int
+
+const (
+	// FailureAsk asks the user whether to retry, continue or abort.
+	FailureAsk FailurePolicy = iota
+	// FailureAbort aborts the automation.
+	FailureAbort
+	// FailureSkip continues with the next action and reports ExitSkipped at the end.
+	FailureSkip
+	// FailureRetry retries the action up to RunOptions.Retries times and aborts when all
+	// attempts fail.
+	FailureRetry
+)
+
+func ParseFailurePolicy(value string) (FailurePolicy, error) {
+	switch value {
+	case "ask":
+		return FailureAsk, nil
+	case "abort":
+		return FailureAbort, nil
+	case "skip":
+		return FailureSkip, nil
+	case "retry":
+		return FailureRetry, nil
+	}
+	return FailureAsk, fmt.Errorf("invalid failure policy '%s', expected ask, abort, skip or retry", value)
+}
+
+// DefaultMaxDepth is the maximum nesting of collected actions when CollectOptions.MaxDepth is not
+// set.
+const DefaultMaxDepth = 20
+
+// CollectOptions control the collection of the actions.
+type CollectOptions struct {
+	// MaxDepth limits the nesting of setup, perform and cleanup actions. 0 means DefaultMaxDepth.
+	MaxDepth int
+}
+
+func (o CollectOptions) maxDepth() int {
+	if o.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return o.MaxDepth
+}
+
+type RunOptions struct {
+	// AutoConfirm executes the collected actions without asking for confirmation.
+	AutoConfirm bool
+	OnFailure   FailurePolicy
+	// Retries is the number of retries for FailureRetry.
+	Retries int
+	// RetryBackoff is the delay before the first retry, it is doubled for every next retry.
+	RetryBackoff time.Duration
+	// DryRun simulates the collected actions against a model of the current state instead of
+	// executing them, and reports the resulting changes and the actions that would fail.
+	DryRun bool
+	// Collect controls the collection of the actions.
+	Collect CollectOptions
+	// Parallelism is the maximum number of independent actions executed at the same time. Values
+	// below 2 execute the actions one by one.
+	Parallelism int
+}
+
+// NonInteractiveRunOptions returns options suitable for running without a terminal: the actions
+// are executed without confirmation and the automation is aborted on the first failure.
+func NonInteractiveRunOptions() RunOptions {
+	return RunOptions{
+		AutoConfirm: true,
+		OnFailure:   FailureAbort,
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const PlanVersion = 1
+
+type Plan struct {
+	Version int        `json:"version" yaml:"version"`
+	Steps   []PlanStep `json:"steps" yaml:"steps"`
+}
+
+type PlanStep struct {
+	Type        string    `json:"type" yaml:"type"`
+	Parameters  []*string `json:"parameters" yaml:"parameters"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+func NewPlan(actions []AutomationAction) *Plan {
+	steps := make([]PlanStep, len(actions))
+	for i, a := range actions {
+		steps[i] = PlanStep{
+			Type:        a.TypeID(),
+			Parameters:  a.Parameters(),
+			Description: a.String(),
+		}
+	}
+	return &Plan{
+		Version: PlanVersion,
+		Steps:   steps,
+	}
+}
+
+// Actions rebuilds the actions of the plan using the registered factories.
+func (p *Plan) Actions() ([]AutomationAction, error) {
+	ret := make([]AutomationAction, len(p.Steps))
+	for i, s := range p.Steps {
+		a, err := NewAction(s.Type, s.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %s", i+1, s.Type, err)
+		}
+		ret[i] = a
+	}
+	return ret, nil
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func WritePlanFile(path string, plan *Plan) error {
+	var data []byte
+	var err error
+	if isYAML(path) {
+		data, err = yaml.Marshal(plan)
+	} else {
+		data, err = json.MarshalIndent(plan, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("unable to encode plan: %s", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// UnmarshalFile decodes a JSON or YAML file into v, depending on the extension of the file.
+func UnmarshalFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, v)
+	} else {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to decode %s: %s", path, err)
+	}
+	return nil
+}
+
+func ReadPlanFile(path string) (*Plan, error) {
+	plan := &Plan{}
+	err := UnmarshalFile(path, plan)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d in %s, expected %d", plan.Version, path, PlanVersion)
+	}
+	return plan, nil
+}
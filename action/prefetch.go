@@ -0,0 +1,107 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// PrefetchBatchSize is the maximum number of UUIDs passed to a Prefetcher at once.
+const PrefetchBatchSize = 50
+
+// Reference identifies a resource read by an action, by the kind of cache entry and its UUID.
+type Reference struct {
+	Kind string
+	UUID string
+}
+
+// Referencing is implemented by actions that can list the resources their Init reads before they
+// are initialized, so these can be loaded in bulk up front.
+type Referencing interface {
+	References() []Reference
+}
+
+// Prefetcher loads the resources of one kind with the given UUIDs in bulk and stores them in the
+// cache of the environment under that kind.
+type Prefetcher func(ctx context.Context, env *Environment, uuids []string) error
+
+var (
+	prefetchersMu sync.RWMutex
+	prefetchers   = make(map[string]Prefetcher)
+)
+
+// RegisterPrefetcher makes a bulk loader available for a kind of cache entry. Like Register, it is
+// intended to be called from an init function and panics when the kind is registered twice.
+func RegisterPrefetcher(kind string, prefetcher Prefetcher) {
+	prefetchersMu.Lock()
+	defer prefetchersMu.Unlock()
+	if prefetcher == nil {
+		panic("action: RegisterPrefetcher prefetcher is nil for " + kind)
+	}
+	if _, dup := prefetchers[kind]; dup {
+		panic("action: RegisterPrefetcher called twice for " + kind)
+	}
+	prefetchers[kind] = prefetcher
+}
+
+// Prefetch loads the resources referenced by the action and, for composites, by all of its
+// children into the cache of the environment. References of kinds without a Prefetcher, to
+// resources that are already cached and to the third account are skipped.
+func Prefetch(ctx context.Context, action AutomationAction, env *Environment) error {
+	if env.Cache == nil {
+		return nil
+	}
+	uuids := make(map[string][]string)
+	collectReferences(action, func(ref Reference) {
+		if ref.UUID == "" || ref.UUID == Account3UUIDPlaceholder || slices.Contains(uuids[ref.Kind], ref.UUID) {
+			return
+		}
+		if _, ok := env.Cache.get(ref.Kind, ref.UUID); ok {
+			return
+		}
+		uuids[ref.Kind] = append(uuids[ref.Kind], ref.UUID)
+	})
+
+	prefetchersMu.RLock()
+	defer prefetchersMu.RUnlock()
+	kinds := make([]string, 0, len(uuids))
+	for kind := range uuids {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	for _, kind := range kinds {
+		prefetcher, ok := prefetchers[kind]
+		if !ok {
+			continue
+		}
+		all := uuids[kind]
+		for start := 0; start < len(all); start += PrefetchBatchSize {
+			end := start + PrefetchBatchSize
+			if end > len(all) {
+				end = len(all)
+			}
+			err := prefetcher(ctx, env, all[start:end])
+			if err != nil {
+				return fmt.Errorf("unable to prefetch %s: %s", kind, err)
+			}
+		}
+	}
+	return nil
+}
+
+func collectReferences(action AutomationAction, f func(ref Reference)) {
+	if referencing, ok := action.(Referencing); ok {
+		for _, ref := range referencing.References() {
+			f(ref)
+		}
+	}
+	if composite, ok := action.(Composite); ok {
+		for _, child := range composite.Children() {
+			collectReferences(child, f)
+		}
+	}
+}
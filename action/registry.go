@@ -0,0 +1,78 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Factory creates a new action from the values returned by its Parameters().
+type Factory func(parameters []*string) (AutomationAction, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes an action type available under its TypeID. It is intended to be called from the
+// init function of the package that defines the action and panics when the type ID is registered
+// twice.
+func Register(typeID string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("action: Register factory is nil for " + typeID)
+	}
+	if _, dup := registry[typeID]; dup {
+		panic("action: Register called twice for " + typeID)
+	}
+	registry[typeID] = factory
+}
+
+func Lookup(typeID string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[typeID]
+	return factory, ok
+}
+
+// RegisteredTypes returns the sorted type IDs of all registered actions.
+func RegisteredTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	ret := make([]string, 0, len(registry))
+	for typeID := range registry {
+		ret = append(ret, typeID)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+func NewAction(typeID string, parameters []*string) (AutomationAction, error) {
+	factory, ok := Lookup(typeID)
+	if !ok {
+		return nil, fmt.Errorf("unknown action type '%s'", typeID)
+	}
+	ret, err := factory(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters for %s: %s", typeID, err)
+	}
+	return ret, nil
+}
+
+// CheckParameters verifies the number of parameters passed to a Factory and that all parameters,
+// except for those at the optional indexes, are set.
+func CheckParameters(parameters []*string, count int, optional ...int) error {
+	if len(parameters) != count {
+		return fmt.Errorf("expected %d parameters, got %d", count, len(parameters))
+	}
+	for i, p := range parameters {
+		if p == nil && !slices.Contains(optional, i) {
+			return fmt.Errorf("parameter %d is required", i+1)
+		}
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/aquilax/truncate"
@@ -13,22 +15,29 @@ import (
 )
 
 type ProgressBarStepper struct {
+	mu    sync.Mutex
 	bar   *progressbar.ProgressBar
 	total int64
 	done  int64
 }
 
 func (s *ProgressBarStepper) Step() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.bar.Add(1)
 	s.done++
 }
 
 func (s *ProgressBarStepper) Done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.bar.Set(s.bar.GetMax())
 	s.bar.Finish()
 }
 
 func (s *ProgressBarStepper) AddSteps(num int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.total += num
 	todo := s.total - s.done
 	factor := float64(s.total) / float64(s.done)
@@ -37,6 +46,8 @@ func (s *ProgressBarStepper) AddSteps(num int64) {
 }
 
 func (s *ProgressBarStepper) Describe(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.bar.Describe(text)
 }
 
@@ -65,26 +76,80 @@ func buildProgressBar(max int64, desc string) *ProgressBarStepper {
 	}
 }
 
-func executeAction(ctx context.Context, action AutomationAction, env *Environment) {
-	action.Init(ctx, env)
-	err := action.Execute(ctx, env)
-	if err != nil {
-		fmt.Printf("\n\nAn error occured during execution of %s:\n%s\n", action.String(), err)
+// executeAction executes the action and handles failures according to the policy in the options.
+// It returns false when the action failed and was skipped, and an error when the automation must
+// be aborted. The cause, when not empty, describes the chain of actions the action is part of.
+func executeAction(ctx context.Context, action AutomationAction, env *Environment, cause string, options RunOptions) (bool, error) {
+	for attempt := 0; ; attempt++ {
+		err := action.Init(ctx, env)
+		if err == nil {
+			err = action.Execute(ctx, env)
+			invalidate(env, action)
+		}
+		if err == nil {
+			return true, nil
+		}
+		retry, ok, err := handleFailure(action, err, cause, attempt, options)
+		if !retry {
+			return ok, err
+		}
+		if options.OnFailure == FailureRetry {
+			time.Sleep(options.RetryBackoff << attempt)
+		}
+	}
+}
+
+// invalidate removes the cached entries of the resources touched by the executed action, or the
+// entire cache when the action does not declare these.
+func invalidate(env *Environment, action AutomationAction) {
+	if touching, ok := action.(Touching); ok {
+		if keys := touching.Touches(); keys != nil {
+			env.Cache.InvalidateKeys(keys)
+			return
+		}
+	}
+	env.Cache.Invalidate()
+}
+
+// failureMu serializes the reporting of failures, including the prompt, of actions executed in
+// parallel.
+var failureMu sync.Mutex
+
+// handleFailure reports the failure of the action and returns whether to retry it. When the action
+// is not retried, it also returns the outcome for executeAction.
+func handleFailure(action AutomationAction, err error, cause string, attempt int, options RunOptions) (bool, bool, error) {
+	failureMu.Lock()
+	defer failureMu.Unlock()
+	fmt.Printf("\n\nAn error occured during execution of %s:\n%s%s\n", action.String(), err, cause)
+	switch options.OnFailure {
+	case FailureAbort:
+		return false, false, err
+	case FailureSkip:
+		fmt.Printf("Continuing with the next action\n")
+		return false, false, nil
+	case FailureRetry:
+		if attempt >= options.Retries {
+			return false, false, fmt.Errorf("giving up after %d retries: %s", attempt, err)
+		}
+		delay := options.RetryBackoff << attempt
+		fmt.Printf("Retrying action in %s (retry %d of %d)\n", delay, attempt+1, options.Retries)
+		return true, false, nil
+	default:
 		prompt := promptui.Select{
 			Label: "How do you want to continue",
 			Items: []string{"Retry", "Continue", "Abort"},
 		}
 		i, _, err := prompt.Run()
 		if err != nil {
-			Abort(action, "Select aborted: %s", err)
+			return false, false, fmt.Errorf("select aborted: %s", err)
 		} else if i == 0 {
 			fmt.Printf("Retrying action\n")
-			executeAction(ctx, action, env)
+			return true, false, nil
 		} else if i == 1 {
 			fmt.Printf("Continuing with the next action\n")
-		} else if i == 2 {
-			Abort(action, "Aborting automation")
+			return false, false, nil
 		}
+		return false, false, errors.New("aborting automation")
 	}
 }
 
@@ -95,44 +160,226 @@ func printActions(actions []AutomationAction) {
 	}
 }
 
-func Run(config AuthenticationConfig, action AutomationAction) {
-	ctx := context.Background()
-	env, err := SetupEnvironment(ctx, config)
-	if err != nil {
-		Abort(action, "unable to authenticate to Topicus KeyHub: %s", err)
-		return
-	}
+func collectActions(ctx context.Context, action AutomationAction, env *Environment, options CollectOptions) ([]AutomationAction, *PlanNode, error) {
 	fmt.Printf("Collecting actions for %s...\n", action.String())
 	bar := buildProgressBar(1, "collecting")
-	actions := Collect(ctx, action, env, bar)
+	actions, tree, err := CollectTree(ctx, action, env, bar, options)
 	bar.Done()
+	if err != nil {
+		return nil, nil, err
+	}
+	return actions, tree, nil
+}
+
+// reportSimulation prints the outcome of the simulation and returns ExitFailure when one or more
+// actions would fail.
+func reportSimulation(simulation *Simulation) int {
+	simulation.Print()
+	if len(simulation.Failures) > 0 {
+		return ExitFailure
+	}
+	return ExitSuccess
+}
 
+// execute executes the collected actions. The tree is nil when the actions were not collected, as
+// for a plan read from a file.
+func execute(ctx context.Context, config AuthenticationConfig, env *Environment, action AutomationAction, actions []AutomationAction, tree *PlanNode, options RunOptions) int {
 	if slices.ContainsFunc(actions, func(action AutomationAction) bool { return action.Requires3() }) {
 		fmt.Print("\nA third authenticated user is required to execute the actions.\n\n")
-		err = AuthenticateAccount3(ctx, config, env)
+		err := AuthenticateAccount3(ctx, config, env)
 		if err != nil {
-			Abort(action, "unable to authenticate to Topicus KeyHub: %s", err)
-			return
+			printError(action, "unable to authenticate to Topicus KeyHub: %s", err)
+			return ExitFailure
 		}
 	}
 
-	printActions(actions)
+	var steps []*PlanNode
+	if tree != nil {
+		printPlan(tree)
+		steps = tree.Steps()
+	} else {
+		printActions(actions)
+	}
 
-	prompt := promptui.Prompt{
-		Label:     "Do you want to continue",
-		IsConfirm: true,
+	if !options.AutoConfirm {
+		prompt := promptui.Prompt{
+			Label:     "Do you want to continue",
+			IsConfirm: true,
+		}
+		_, err := prompt.Run()
+		if err != nil {
+			printError(action, "Aborting automation")
+			return ExitFailure
+		}
 	}
-	_, err = prompt.Run()
+
+	bar := buildProgressBar(int64(len(actions)), "Starting")
+	skipped, err := executeAll(ctx, env, actions, steps, options, bar)
 	if err != nil {
-		Abort(action, "Aborting automation")
-		return
+		return ExitFailure
 	}
+	bar.Done()
 
-	bar = buildProgressBar(int64(len(actions)), "Starting")
-	for _, a := range actions {
-		bar.Describe(fmt.Sprintf("%-60s", truncate.Truncate(a.Progress(), 60, truncate.DEFAULT_OMISSION, truncate.PositionEnd)))
+	if skipped > 0 {
+		fmt.Printf("%d of %d actions failed and were skipped\n", skipped, len(actions))
+		return ExitSkipped
+	}
+	return ExitSuccess
+}
+
+type actionResult struct {
+	index int
+	ok    bool
+	err   error
+}
+
+// executeAll executes the actions on a pool of options.Parallelism workers. An action is started
+// only when all actions it depends on have finished, and among the ready actions the first in the
+// plan goes first, so with a single worker the actions execute in the order of the plan. It
+// returns the number of skipped actions, or an error when the automation was aborted, after the
+// running actions have finished.
+func executeAll(ctx context.Context, env *Environment, actions []AutomationAction, steps []*PlanNode, options RunOptions, bar *ProgressBarStepper) (int, error) {
+	workers := options.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	sched := newSchedule(actions, steps, env)
+	ready := sched.ready()
+	results := make(chan actionResult)
+	running := 0
+	skipped := 0
+	var abort error
+	for {
+		for abort == nil && running < workers && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			cause := ""
+			if steps != nil {
+				cause = steps[i].Cause()
+			}
+			bar.Describe(fmt.Sprintf("%-60s", truncate.Truncate(actions[i].Progress(), 60, truncate.DEFAULT_OMISSION, truncate.PositionEnd)))
+			running++
+			go func(i int, cause string) {
+				ok, err := executeAction(ctx, actions[i], env, cause, options)
+				results <- actionResult{index: i, ok: ok, err: err}
+			}(i, cause)
+		}
+		if running == 0 {
+			return skipped, abort
+		}
+		r := <-results
+		running--
 		bar.Step()
-		executeAction(ctx, a, env)
+		if r.err != nil {
+			if abort == nil {
+				printError(actions[r.index], "%s", r.err)
+				abort = r.err
+			}
+			continue
+		}
+		if !r.ok {
+			skipped++
+		}
+		ready = append(ready, sched.done(r.index)...)
+		slices.Sort(ready)
 	}
-	bar.Done()
+}
+
+func Run(config AuthenticationConfig, action AutomationAction) {
+	code := RunWithOptions(config, action, RunOptions{})
+	if code != ExitSuccess {
+		os.Exit(code)
+	}
+}
+
+// RunWithOptions collects and executes the actions for the given action and returns the exit code
+// reflecting the outcome: ExitSuccess, ExitFailure when the automation was aborted or ExitSkipped
+// when one or more failed actions were skipped.
+func RunWithOptions(config AuthenticationConfig, action AutomationAction, options RunOptions) int {
+	ctx := context.Background()
+	env, err := SetupEnvironment(ctx, config)
+	if err != nil {
+		printError(action, "unable to authenticate to Topicus KeyHub: %s", err)
+		return ExitFailure
+	}
+	if options.DryRun {
+		fmt.Printf("Simulating actions for %s...\n", action.String())
+		bar := buildProgressBar(1, "collecting")
+		simulation, err := Simulate(ctx, action, env, bar, options.Collect)
+		if err != nil {
+			printError(nil, "%s", err)
+			return ExitFailure
+		}
+		bar.Done()
+		return reportSimulation(simulation)
+	}
+	actions, tree, err := collectActions(ctx, action, env, options.Collect)
+	if err != nil {
+		printError(nil, "%s", err)
+		return ExitFailure
+	}
+	return execute(ctx, config, env, action, actions, tree, options)
+}
+
+// ExportPlan collects the actions for the given action and writes them to a plan file, without
+// executing anything. The plan can be reviewed and executed later with RunPlan.
+func ExportPlan(config AuthenticationConfig, action AutomationAction, path string, options CollectOptions) error {
+	ctx := context.Background()
+	env, err := SetupEnvironment(ctx, config)
+	if err != nil {
+		return fmt.Errorf("unable to authenticate to Topicus KeyHub: %s", err)
+	}
+	actions, tree, err := collectActions(ctx, action, env, options)
+	if err != nil {
+		return err
+	}
+	printPlan(tree)
+
+	err = WritePlanFile(path, NewPlan(actions))
+	if err != nil {
+		return fmt.Errorf("unable to write plan to %s: %s", path, err)
+	}
+	fmt.Printf("The plan has been written to %s\n", path)
+	return nil
+}
+
+// RunPlan executes the steps of a previously exported plan exactly as listed. The packages
+// defining the actions in the plan must be imported to register their types.
+func RunPlan(config AuthenticationConfig, plan *Plan) {
+	code := RunPlanWithOptions(config, plan, RunOptions{})
+	if code != ExitSuccess {
+		os.Exit(code)
+	}
+}
+
+// RunPlanWithOptions executes the steps of a plan and returns the exit code like RunWithOptions.
+func RunPlanWithOptions(config AuthenticationConfig, plan *Plan, options RunOptions) int {
+	actions, err := plan.Actions()
+	if err != nil {
+		printError(nil, "invalid plan: %s", err)
+		return ExitFailure
+	}
+	ctx := context.Background()
+	env, err := SetupEnvironment(ctx, config)
+	if err != nil {
+		printError(nil, "unable to authenticate to Topicus KeyHub: %s", err)
+		return ExitFailure
+	}
+	if options.DryRun {
+		err = Prefetch(ctx, NewSequence("plan", actions...), env)
+		if err != nil {
+			printError(nil, "%s", err)
+			return ExitFailure
+		}
+		c := &collector{ctx: ctx, env: env, model: NewModel()}
+		for _, a := range actions {
+			err = c.init(a)
+			if err != nil {
+				printError(a, "%s", err)
+				return ExitFailure
+			}
+		}
+		return reportSimulation(Replay(actions, env, c.model))
+	}
+	return execute(ctx, config, env, nil, actions, nil, options)
 }
@@ -0,0 +1,101 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/automation-framework/keyhubtest"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+type nopStepper struct{}
+
+func (nopStepper) Step()              {}
+func (nopStepper) AddSteps(num int64) {}
+func (nopStepper) Done()              {}
+
+func TestRunWithOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options action.RunOptions
+		applied bool
+	}{
+		{"sequential", action.RunOptions{AutoConfirm: true, OnFailure: action.FailureAbort}, true},
+		{"parallel", action.RunOptions{AutoConfirm: true, OnFailure: action.FailureAbort, Parallelism: 4}, true},
+		{"dry run", action.RunOptions{DryRun: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := keyhubtest.NewServer()
+			defer server.Close()
+			admin1 := server.AddAdministrator("admin1")
+			admin2 := server.AddAdministrator("admin2")
+
+			users := make([]*keyhubtest.Account, 0)
+			teams := make([]*keyhubtest.Group, 0)
+			steps := make([]action.AutomationAction, 0)
+			for _, name := range []string{"a", "b", "c"} {
+				user := server.AddAccount("user-" + name)
+				team := server.AddGroup("Team " + name)
+				users = append(users, user)
+				teams = append(teams, team)
+				steps = append(steps, actions.NewAccountInGroup(user.UUID, team.UUID, action.Ptr(models.NORMAL_GROUPGROUPRIGHTS)))
+			}
+
+			code := action.RunWithOptions(server.Config(admin1, admin2), action.NewSequence("Add users", steps...), tt.options)
+			if code != action.ExitSuccess {
+				t.Fatalf("exit code %d, want %d", code, action.ExitSuccess)
+			}
+			for i, team := range teams {
+				rights := server.Rights(team, users[i])
+				if tt.applied && (rights == nil || *rights != models.NORMAL_GROUPGROUPRIGHTS) {
+					t.Errorf("%s is not a normal member of %s", users[i].Username, team.Name)
+				}
+				if !tt.applied && rights != nil {
+					t.Errorf("%s was added to %s", users[i].Username, team.Name)
+				}
+				if server.Rights(team, admin1) != nil {
+					t.Errorf("admin1 was not removed from %s", team.Name)
+				}
+			}
+		})
+	}
+}
+
+// TestRunPlan runs an exported plan read back from a file, so its actions are scheduled before
+// they are initialized.
+func TestRunPlan(t *testing.T) {
+	for _, file := range []string{"plan.json", "plan.yaml"} {
+		t.Run(file, func(t *testing.T) {
+			server := keyhubtest.NewServer()
+			defer server.Close()
+			admin1 := server.AddAdministrator("admin1")
+			admin2 := server.AddAdministrator("admin2")
+			system := server.AddSystem("Backend", server.AddGroup("Old owners"))
+			newOwners := server.AddGroup("New owners")
+			config := server.Config(admin1, admin2)
+
+			path := filepath.Join(t.TempDir(), file)
+			err := action.ExportPlan(config, actions.NewSystemOwnedByGroup(system.UUID, newOwners.UUID), path, action.CollectOptions{})
+			if err != nil {
+				t.Fatalf("export plan: %s", err)
+			}
+			plan, err := action.ReadPlanFile(path)
+			if err != nil {
+				t.Fatalf("read plan: %s", err)
+			}
+			code := action.RunPlanWithOptions(config, plan, action.RunOptions{AutoConfirm: true, OnFailure: action.FailureAbort, Parallelism: 4})
+			if code != action.ExitSuccess {
+				t.Fatalf("exit code %d, want %d", code, action.ExitSuccess)
+			}
+			if server.SystemOwner(system) != newOwners {
+				t.Errorf("Backend is not owned by New owners")
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Touching is implemented by actions that can list every resource they read or change once they
+// are initialized, including the resources found by Init, such as the current owner of a group on
+// system. The keys are UUIDs or other cache keys of the resources, such as the name of a group that
+// is yet to be created. An action returns nil when it cannot tell, for example because a resource
+// it depends on does not exist yet or because it is not initialized, as the actions of a plan read
+// from a file are scheduled before Init has run.
+//
+// The runner executes actions touching different resources in parallel and, after executing an
+// action, invalidates the cached entries of the resources it touches. Actions that do not implement
+// Touching run on their own and invalidate the entire cache.
+type Touching interface {
+	Touches() []string
+}
+
+// schedule holds the dependencies between the collected actions. An action depends on an earlier
+// action when both touch the same resource, when both belong to the same unit of the plan tree or
+// when either does not declare the resources it touches.
+type schedule struct {
+	dependents [][]int
+	waiting    []int
+}
+
+// newSchedule derives the dependencies of the actions. A unit is a child of the root composite
+// with all of its setup, perform and cleanup steps, which must run in the collected order. Units
+// sharing steps eliminated against each other are merged, because a step of one may now depend on
+// a setup step of the other. Without a tree, all actions form a single unit.
+func newSchedule(actions []AutomationAction, steps []*PlanNode, env *Environment) *schedule {
+	units := planUnits(steps, len(actions))
+	ret := &schedule{
+		dependents: make([][]int, len(actions)),
+		waiting:    make([]int, len(actions)),
+	}
+	last := make(map[string]int)
+	barrier := -1
+	dependsOn := func(i int, deps map[int]bool) {
+		for d := range deps {
+			ret.dependents[d] = append(ret.dependents[d], i)
+			ret.waiting[i]++
+		}
+	}
+	for i, a := range actions {
+		deps := make(map[int]bool)
+		if barrier >= 0 {
+			deps[barrier] = true
+		}
+		keys := resourceKeys(a, env)
+		if keys == nil {
+			// The action may touch anything, it waits for all earlier actions and all later
+			// actions wait for it.
+			for _, l := range last {
+				deps[l] = true
+			}
+			dependsOn(i, deps)
+			barrier = i
+			last = make(map[string]int)
+			continue
+		}
+		keys = append(keys, fmt.Sprintf("unit:%d", units[i]))
+		for _, key := range keys {
+			if l, ok := last[key]; ok {
+				deps[l] = true
+			}
+			last[key] = i
+		}
+		dependsOn(i, deps)
+	}
+	return ret
+}
+
+// planUnits returns the unit of every one of the count steps, merging the units of eliminated
+// inverse steps. Without steps, all are in the same unit.
+func planUnits(steps []*PlanNode, count int) []int {
+	ret := make([]int, count)
+	if len(steps) == 0 {
+		return ret
+	}
+	root := steps[0]
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	if !isComposite(root.Action) {
+		return ret
+	}
+
+	unitOf := func(node *PlanNode) *PlanNode {
+		for node.Parent != root {
+			node = node.Parent
+		}
+		return node
+	}
+	merged := make(map[*PlanNode]*PlanNode)
+	find := func(unit *PlanNode) *PlanNode {
+		for merged[unit] != nil {
+			unit = merged[unit]
+		}
+		return unit
+	}
+	root.walk(func(node *PlanNode, _ int) {
+		if node != root && node.Inverse != nil {
+			a := find(unitOf(node))
+			b := find(unitOf(node.Inverse))
+			if a != b {
+				merged[a] = b
+			}
+		}
+	})
+	ids := make(map[*PlanNode]int)
+	for i, step := range steps {
+		unit := find(unitOf(step))
+		if _, ok := ids[unit]; !ok {
+			ids[unit] = len(ids)
+		}
+		ret[i] = ids[unit]
+	}
+	return ret
+}
+
+// resourceKeys returns the keys of the resources the action touches, except for the authenticated
+// accounts, which take part in almost every action. It returns nil when the action does not declare
+// the resources it touches.
+func resourceKeys(action AutomationAction, env *Environment) []string {
+	touching, ok := action.(Touching)
+	if !ok {
+		return nil
+	}
+	keys := touching.Touches()
+	if keys == nil {
+		return nil
+	}
+	ret := make([]string, 0)
+	for _, key := range keys {
+		if isAuthenticatedAccount(env, key) || slices.Contains(ret, key) {
+			continue
+		}
+		ret = append(ret, key)
+	}
+	return ret
+}
+
+func isAuthenticatedAccount(env *Environment, uuid string) bool {
+	if uuid == Account3UUIDPlaceholder {
+		return true
+	}
+	for _, account := range []*AuthenticatedAccount{env.Account1, env.Account2, env.Account3} {
+		if account != nil && *account.Account.GetUuid() == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// ready returns the indexes of the actions that do not wait for any other action.
+func (s *schedule) ready() []int {
+	ret := make([]int, 0)
+	for i, w := range s.waiting {
+		if w == 0 {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// done marks the action as finished and returns the dependents that became ready.
+func (s *schedule) done(i int) []int {
+	ret := make([]int, 0)
+	for _, d := range s.dependents[i] {
+		s.waiting[d]--
+		if s.waiting[d] == 0 {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"context"
+	"fmt"
+)
+
+// Composite is implemented by actions that only group other actions. A composite does not execute
+// a step of its own: it is left out of the collected actions and, when it is the root, every child
+// is scheduled as an independent part of the plan.
+type Composite interface {
+	AutomationAction
+	// Children returns the grouped actions, which are also returned by Perform.
+	Children() []AutomationAction
+}
+
+func isComposite(action AutomationAction) bool {
+	_, ok := action.(Composite)
+	return ok
+}
+
+// sequence is a composite action that performs a list of child actions. Collecting a sequence
+// yields the actions of all children as one list, so inverse actions are eliminated across the
+// whole batch.
+type sequence struct {
+	description string
+	children    []AutomationAction
+}
+
+func NewSequence(description string, children ...AutomationAction) AutomationAction {
+	return &sequence{
+		description: description,
+		children:    children,
+	}
+}
+
+func (a *sequence) TypeID() string {
+	return "sequence"
+}
+
+func (a *sequence) Parameters() []*string {
+	return []*string{&a.description}
+}
+
+func (a *sequence) Init(ctx context.Context, env *Environment) error {
+	return nil
+}
+
+func (a *sequence) IsSatisfied() bool {
+	return false
+}
+
+func (a *sequence) Requires3() bool {
+	return false
+}
+
+func (a *sequence) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *sequence) Execute(ctx context.Context, env *Environment) error {
+	return nil
+}
+
+func (a *sequence) Setup(env *Environment) []AutomationAction {
+	return make([]AutomationAction, 0)
+}
+
+func (a *sequence) Perform(env *Environment) []AutomationAction {
+	return a.children
+}
+
+func (a *sequence) Children() []AutomationAction {
+	return a.children
+}
+
+func (a *sequence) Revert() AutomationAction {
+	return nil
+}
+
+func (a *sequence) Progress() string {
+	return a.description
+}
+
+func (a *sequence) String() string {
+	if len(a.children) == 1 {
+		return fmt.Sprintf("%s (1 action)", a.description)
+	}
+	return fmt.Sprintf("%s (%d actions)", a.description, len(a.children))
+}
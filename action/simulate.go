@@ -0,0 +1,518 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// Simulated is implemented by actions that can describe their effect on a Model, to replay a plan
+// without executing it.
+type Simulated interface {
+	// Observe records the live state read by Init in the model.
+	Observe(model *Model)
+	// Simulate checks the preconditions of the action against the model and, when they hold,
+	// applies the effect of the action to the model.
+	Simulate(env *Environment, model *Model) error
+}
+
+type pair struct {
+	subject string
+	member  string
+}
+
+type clientPermission struct {
+	client         string
+	permissionType models.ClientOAuth2ClientPermissionType
+	target         string
+}
+
+type authorization struct {
+	group    string
+	authType models.RequestAuthorizingGroupType
+}
+
+// Model is a snapshot of the state of KeyHub as far as it is relevant to the actions, keyed by
+// UUID. Entries that were never observed are assumed to be absent.
+type Model struct {
+	groups               map[string]bool
+	classifications      map[string]string
+	names                map[string]string
+	memberships          map[pair]models.GroupGroupRights
+	authorizations       map[authorization]string
+	groupsOnSystem       map[pair]bool
+	groupOnSystemOwners  map[string]string
+	systemOwners         map[string]string
+	clientOwners         map[string]string
+	serviceAccountAdmins map[string]string
+	vaultRecords         map[pair]bool
+	clientPermissions    map[clientPermission]bool
+	provisioning         map[pair]bool
+	orgUnitMembers       map[pair]bool
+	groupOrgUnits        map[string]string
+}
+
+func NewModel() *Model {
+	return &Model{
+		groups:               make(map[string]bool),
+		classifications:      make(map[string]string),
+		names:                make(map[string]string),
+		memberships:          make(map[pair]models.GroupGroupRights),
+		authorizations:       make(map[authorization]string),
+		groupsOnSystem:       make(map[pair]bool),
+		groupOnSystemOwners:  make(map[string]string),
+		systemOwners:         make(map[string]string),
+		clientOwners:         make(map[string]string),
+		serviceAccountAdmins: make(map[string]string),
+		vaultRecords:         make(map[pair]bool),
+		clientPermissions:    make(map[clientPermission]bool),
+		provisioning:         make(map[pair]bool),
+		orgUnitMembers:       make(map[pair]bool),
+		groupOrgUnits:        make(map[string]string),
+	}
+}
+
+func (m *Model) Clone() *Model {
+	return &Model{
+		groups:               maps.Clone(m.groups),
+		classifications:      maps.Clone(m.classifications),
+		names:                maps.Clone(m.names),
+		memberships:          maps.Clone(m.memberships),
+		authorizations:       maps.Clone(m.authorizations),
+		groupsOnSystem:       maps.Clone(m.groupsOnSystem),
+		groupOnSystemOwners:  maps.Clone(m.groupOnSystemOwners),
+		systemOwners:         maps.Clone(m.systemOwners),
+		clientOwners:         maps.Clone(m.clientOwners),
+		serviceAccountAdmins: maps.Clone(m.serviceAccountAdmins),
+		vaultRecords:         maps.Clone(m.vaultRecords),
+		clientPermissions:    maps.Clone(m.clientPermissions),
+		provisioning:         maps.Clone(m.provisioning),
+		orgUnitMembers:       maps.Clone(m.orgUnitMembers),
+		groupOrgUnits:        maps.Clone(m.groupOrgUnits),
+	}
+}
+
+// SetName registers a human readable name for the object with the given UUID.
+func (m *Model) SetName(uuid string, name string) {
+	m.names[uuid] = name
+}
+
+// Name returns the name registered for the UUID, or the UUID itself.
+func (m *Model) Name(uuid string) string {
+	if uuid == Account3UUIDPlaceholder {
+		return "account 3"
+	}
+	if name, ok := m.names[uuid]; ok {
+		return name
+	}
+	return uuid
+}
+
+// ObserveAccount records the name of the account.
+func (m *Model) ObserveAccount(account models.AuthAccountPrimerable) {
+	if account != nil && account.GetUuid() != nil && account.GetUsername() != nil {
+		m.SetName(*account.GetUuid(), *account.GetUsername())
+	}
+}
+
+// ObserveGroup records the name, organizational unit, classification and authorizing groups of the
+// group, and its members when they were fetched with the 'accounts' additional object.
+func (m *Model) ObserveGroup(group models.GroupGroupable) {
+	if group == nil || group.GetUuid() == nil {
+		return
+	}
+	groupUUID := *group.GetUuid()
+	m.SetName(groupUUID, *group.GetName())
+	if len(group.GetLinks()) == 0 {
+		// The group is referred to by name and is not created yet.
+		return
+	}
+	m.SetGroupExists(*group.GetName(), true)
+	if orgUnit := group.GetOrganizationalUnit(); orgUnit != nil {
+		m.SetName(*orgUnit.GetUuid(), *orgUnit.GetName())
+		m.SetGroupOrganizationalUnit(groupUUID, *orgUnit.GetUuid())
+	}
+	if classification := group.GetClassification(); classification != nil {
+		m.SetName(*classification.GetUuid(), *classification.GetName())
+		m.SetClassification(groupUUID, *classification.GetUuid())
+	}
+	for authType, authorizing := range map[models.RequestAuthorizingGroupType]models.GroupGroupPrimerable{
+		models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE:     group.GetAuthorizingGroupAuditing(),
+		models.DELEGATION_REQUESTAUTHORIZINGGROUPTYPE:   group.GetAuthorizingGroupDelegation(),
+		models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE:   group.GetAuthorizingGroupMembership(),
+		models.PROVISIONING_REQUESTAUTHORIZINGGROUPTYPE: group.GetAuthorizingGroupProvisioning(),
+	} {
+		if authorizing != nil {
+			m.SetName(*authorizing.GetUuid(), *authorizing.GetName())
+			m.SetAuthorizingGroup(groupUUID, authType, *authorizing.GetUuid())
+		}
+	}
+	additional := group.GetAdditionalObjects()
+	if additional != nil && additional.GetAccounts() != nil {
+		for _, member := range additional.GetAccounts().GetItems() {
+			m.SetName(*member.GetUuid(), *member.GetUsername())
+			m.SetRights(groupUUID, *member.GetUuid(), *member.GetRights())
+		}
+	}
+}
+
+// GroupExists returns whether a group with the given name exists. Groups are identified by name,
+// as the UUID of a group is not known before it is created.
+func (m *Model) GroupExists(name string) bool {
+	return m.groups[name]
+}
+
+func (m *Model) SetGroupExists(name string, exists bool) {
+	if exists {
+		m.groups[name] = true
+	} else {
+		delete(m.groups, name)
+	}
+}
+
+// Classification returns the UUID of the classification of the group, or an empty string.
+func (m *Model) Classification(groupUUID string) string {
+	return m.classifications[groupUUID]
+}
+
+func (m *Model) SetClassification(groupUUID string, classificationUUID string) {
+	m.classifications[groupUUID] = classificationUUID
+}
+
+// Rights returns the rights of the account in the group and whether it is a member at all.
+func (m *Model) Rights(groupUUID string, accountUUID string) (models.GroupGroupRights, bool) {
+	rights, ok := m.memberships[pair{groupUUID, accountUUID}]
+	return rights, ok
+}
+
+func (m *Model) IsMember(groupUUID string, accountUUID string) bool {
+	_, ok := m.Rights(groupUUID, accountUUID)
+	return ok
+}
+
+func (m *Model) IsManager(groupUUID string, accountUUID string) bool {
+	rights, ok := m.Rights(groupUUID, accountUUID)
+	return ok && rights == models.MANAGER_GROUPGROUPRIGHTS
+}
+
+func (m *Model) SetRights(groupUUID string, accountUUID string, rights models.GroupGroupRights) {
+	m.memberships[pair{groupUUID, accountUUID}] = rights
+}
+
+func (m *Model) RemoveMember(groupUUID string, accountUUID string) {
+	delete(m.memberships, pair{groupUUID, accountUUID})
+}
+
+// AuthorizingGroup returns the UUID of the group handling the authorization of the given type on
+// the group, or an empty string.
+func (m *Model) AuthorizingGroup(groupUUID string, authType models.RequestAuthorizingGroupType) string {
+	return m.authorizations[authorization{groupUUID, authType}]
+}
+
+// SetAuthorizingGroup connects the authorizing group, or disconnects the authorization when the
+// authorizing group is empty.
+func (m *Model) SetAuthorizingGroup(groupUUID string, authType models.RequestAuthorizingGroupType, authorizingGroupUUID string) {
+	if authorizingGroupUUID == "" {
+		delete(m.authorizations, authorization{groupUUID, authType})
+	} else {
+		m.authorizations[authorization{groupUUID, authType}] = authorizingGroupUUID
+	}
+}
+
+// GroupOnSystemOwner returns the UUID of the owner of the group on system, or an empty string.
+func (m *Model) GroupOnSystemOwner(gosUUID string) string {
+	return m.groupOnSystemOwners[gosUUID]
+}
+
+func (m *Model) SetGroupOnSystemOwner(gosUUID string, ownerUUID string) {
+	m.groupOnSystemOwners[gosUUID] = ownerUUID
+}
+
+// GroupOnSystemExists returns whether a group on system with the given name exists on the system.
+func (m *Model) GroupOnSystemExists(systemUUID string, nameInSystem string) bool {
+	return m.groupsOnSystem[pair{systemUUID, nameInSystem}]
+}
+
+func (m *Model) SetGroupOnSystemExists(systemUUID string, nameInSystem string, exists bool) {
+	if exists {
+		m.groupsOnSystem[pair{systemUUID, nameInSystem}] = true
+	} else {
+		delete(m.groupsOnSystem, pair{systemUUID, nameInSystem})
+	}
+}
+
+// SystemOwner returns the UUID of the owner of the provisioned system, or an empty string.
+func (m *Model) SystemOwner(systemUUID string) string {
+	return m.systemOwners[systemUUID]
+}
+
+func (m *Model) SetSystemOwner(systemUUID string, ownerUUID string) {
+	m.systemOwners[systemUUID] = ownerUUID
+}
+
+// ClientOwner returns the UUID of the owner of the client application, or an empty string.
+func (m *Model) ClientOwner(clientUUID string) string {
+	return m.clientOwners[clientUUID]
+}
+
+func (m *Model) SetClientOwner(clientUUID string, ownerUUID string) {
+	m.clientOwners[clientUUID] = ownerUUID
+}
+
+// ServiceAccountAdmin returns the UUID of the technical administrator of the service account, or
+// an empty string.
+func (m *Model) ServiceAccountAdmin(serviceAccountUUID string) string {
+	return m.serviceAccountAdmins[serviceAccountUUID]
+}
+
+func (m *Model) SetServiceAccountAdmin(serviceAccountUUID string, adminUUID string) {
+	m.serviceAccountAdmins[serviceAccountUUID] = adminUUID
+}
+
+// HasVaultRecord returns whether the vault of the group contains a record with the given name.
+func (m *Model) HasVaultRecord(groupUUID string, name string) bool {
+	return m.vaultRecords[pair{groupUUID, name}]
+}
+
+func (m *Model) SetVaultRecord(groupUUID string, name string, present bool) {
+	if present {
+		m.vaultRecords[pair{groupUUID, name}] = true
+	} else {
+		delete(m.vaultRecords, pair{groupUUID, name})
+	}
+}
+
+// HasClientPermission returns whether the client holds the permission on the target group or
+// system.
+func (m *Model) HasClientPermission(clientUUID string, permissionType models.ClientOAuth2ClientPermissionType, targetUUID string) bool {
+	return m.clientPermissions[clientPermission{clientUUID, permissionType, targetUUID}]
+}
+
+func (m *Model) SetClientPermission(clientUUID string, permissionType models.ClientOAuth2ClientPermissionType, targetUUID string, granted bool) {
+	if granted {
+		m.clientPermissions[clientPermission{clientUUID, permissionType, targetUUID}] = true
+	} else {
+		delete(m.clientPermissions, clientPermission{clientUUID, permissionType, targetUUID})
+	}
+}
+
+// IsProvisioned returns whether the group is one of the provisioned groups of the group on system.
+func (m *Model) IsProvisioned(gosUUID string, groupUUID string) bool {
+	return m.provisioning[pair{gosUUID, groupUUID}]
+}
+
+func (m *Model) SetProvisioned(gosUUID string, groupUUID string, provisioned bool) {
+	if provisioned {
+		m.provisioning[pair{gosUUID, groupUUID}] = true
+	} else {
+		delete(m.provisioning, pair{gosUUID, groupUUID})
+	}
+}
+
+func (m *Model) InOrganizationalUnit(orgUnitUUID string, accountUUID string) bool {
+	return m.orgUnitMembers[pair{orgUnitUUID, accountUUID}]
+}
+
+func (m *Model) SetInOrganizationalUnit(orgUnitUUID string, accountUUID string, member bool) {
+	if member {
+		m.orgUnitMembers[pair{orgUnitUUID, accountUUID}] = true
+	} else {
+		delete(m.orgUnitMembers, pair{orgUnitUUID, accountUUID})
+	}
+}
+
+// GroupOrganizationalUnit returns the UUID of the organizational unit of the group, or an empty
+// string.
+func (m *Model) GroupOrganizationalUnit(groupUUID string) string {
+	return m.groupOrgUnits[groupUUID]
+}
+
+func (m *Model) SetGroupOrganizationalUnit(groupUUID string, orgUnitUUID string) {
+	m.groupOrgUnits[groupUUID] = orgUnitUUID
+}
+
+func describeRights(rights models.GroupGroupRights) string {
+	if rights == models.MANAGER_GROUPGROUPRIGHTS {
+		return "manager"
+	}
+	return "member"
+}
+
+func diffMap[K comparable, V comparable](before map[K]V, after map[K]V, describe func(key K, value V) string) []string {
+	ret := make([]string, 0)
+	for key, value := range after {
+		old, ok := before[key]
+		if !ok {
+			ret = append(ret, "+ "+describe(key, value))
+		} else if old != value {
+			ret = append(ret, fmt.Sprintf("~ %s (was %s)", describe(key, value), describe(key, old)))
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			ret = append(ret, "- "+describe(key, value))
+		}
+	}
+	return ret
+}
+
+// Diff returns the differences between the given model and this model, one line per change,
+// prefixed with '+' for additions, '-' for removals and '~' for modifications.
+func (m *Model) Diff(before *Model) []string {
+	ret := make([]string, 0)
+	ret = append(ret, diffMap(before.groups, m.groups, func(name string, _ bool) string {
+		return fmt.Sprintf("group %s exists", name)
+	})...)
+	ret = append(ret, diffMap(before.classifications, m.classifications, func(group string, classification string) string {
+		return fmt.Sprintf("%s is classified as %s", m.Name(group), m.Name(classification))
+	})...)
+	ret = append(ret, diffMap(before.memberships, m.memberships, func(key pair, rights models.GroupGroupRights) string {
+		return fmt.Sprintf("%s is %s of %s", m.Name(key.member), describeRights(rights), m.Name(key.subject))
+	})...)
+	ret = append(ret, diffMap(before.authorizations, m.authorizations, func(key authorization, authorizing string) string {
+		return fmt.Sprintf("%s authorizes %s for %s", m.Name(authorizing), strings.ToLower(key.authType.String()), m.Name(key.group))
+	})...)
+	ret = append(ret, diffMap(before.groupsOnSystem, m.groupsOnSystem, func(key pair, _ bool) string {
+		return fmt.Sprintf("group on system %s exists on %s", key.member, m.Name(key.subject))
+	})...)
+	ret = append(ret, diffMap(before.groupOnSystemOwners, m.groupOnSystemOwners, func(gos string, owner string) string {
+		return fmt.Sprintf("%s owns %s", m.Name(owner), m.Name(gos))
+	})...)
+	ret = append(ret, diffMap(before.systemOwners, m.systemOwners, func(system string, owner string) string {
+		return fmt.Sprintf("%s owns system %s", m.Name(owner), m.Name(system))
+	})...)
+	ret = append(ret, diffMap(before.clientOwners, m.clientOwners, func(client string, owner string) string {
+		return fmt.Sprintf("%s owns application %s", m.Name(owner), m.Name(client))
+	})...)
+	ret = append(ret, diffMap(before.serviceAccountAdmins, m.serviceAccountAdmins, func(serviceAccount string, admin string) string {
+		return fmt.Sprintf("%s administers service account %s", m.Name(admin), m.Name(serviceAccount))
+	})...)
+	ret = append(ret, diffMap(before.vaultRecords, m.vaultRecords, func(key pair, _ bool) string {
+		return fmt.Sprintf("vault record %s is in the vault of %s", key.member, m.Name(key.subject))
+	})...)
+	ret = append(ret, diffMap(before.clientPermissions, m.clientPermissions, func(key clientPermission, _ bool) string {
+		return fmt.Sprintf("%s holds %s on %s", m.Name(key.client), key.permissionType.String(), m.Name(key.target))
+	})...)
+	ret = append(ret, diffMap(before.provisioning, m.provisioning, func(key pair, _ bool) string {
+		return fmt.Sprintf("%s is provisioned to %s", m.Name(key.member), m.Name(key.subject))
+	})...)
+	ret = append(ret, diffMap(before.orgUnitMembers, m.orgUnitMembers, func(key pair, _ bool) string {
+		return fmt.Sprintf("%s is in organizational unit %s", m.Name(key.member), m.Name(key.subject))
+	})...)
+	ret = append(ret, diffMap(before.groupOrgUnits, m.groupOrgUnits, func(group string, orgUnit string) string {
+		return fmt.Sprintf("group %s is in organizational unit %s", m.Name(group), m.Name(orgUnit))
+	})...)
+	slices.SortFunc(ret, func(a, b string) int { return strings.Compare(a[2:], b[2:]) })
+	return ret
+}
+
+// SimulationFailure is an action whose preconditions would not hold when it is executed.
+type SimulationFailure struct {
+	Action AutomationAction
+	Err    error
+}
+
+// Simulation is the result of replaying a plan against a model of the live state.
+type Simulation struct {
+	Actions []AutomationAction
+	// Tree is the plan tree of the actions, or nil when they were not collected.
+	Tree *PlanNode
+	// Initial is the state observed while collecting the actions.
+	Initial *Model
+	// Final is the state after all actions that could be simulated have been applied.
+	Final    *Model
+	Failures []SimulationFailure
+	// Unsimulated lists the actions that do not implement Simulated and were ignored.
+	Unsimulated []AutomationAction
+}
+
+// Simulate collects the actions like Collect, while observing the live state in a model, and then
+// replays the collected actions against that model. Actions whose preconditions fail are reported
+// and do not change the model.
+func Simulate(ctx context.Context, action AutomationAction, env *Environment, stepper Stepper, options CollectOptions) (*Simulation, error) {
+	c := &collector{ctx: ctx, env: env, stepper: stepper, model: NewModel(), options: options}
+	actions, tree, err := c.collect(action)
+	if err != nil {
+		return nil, err
+	}
+	ret := Replay(actions, env, c.model)
+	ret.Tree = tree
+	return ret, nil
+}
+
+// Replay applies the actions to a copy of the model, in order.
+func Replay(actions []AutomationAction, env *Environment, model *Model) *Simulation {
+	for _, account := range []*AuthenticatedAccount{env.Account1, env.Account2, env.Account3} {
+		if account != nil {
+			model.ObserveAccount(account.Account)
+		}
+	}
+	ret := &Simulation{
+		Actions:     actions,
+		Initial:     model,
+		Final:       model.Clone(),
+		Failures:    make([]SimulationFailure, 0),
+		Unsimulated: make([]AutomationAction, 0),
+	}
+	for _, a := range actions {
+		simulated, ok := a.(Simulated)
+		if !ok {
+			ret.Unsimulated = append(ret.Unsimulated, a)
+			continue
+		}
+		err := simulated.Simulate(env, ret.Final)
+		if err != nil {
+			ret.Failures = append(ret.Failures, SimulationFailure{Action: a, Err: err})
+		}
+	}
+	return ret
+}
+
+func (s *Simulation) Print() {
+	if s.Tree != nil {
+		printPlan(s.Tree)
+	} else {
+		printActions(s.Actions)
+	}
+	fmt.Printf("\nThe simulated plan results in the following changes:\n")
+	diff := s.Final.Diff(s.Initial)
+	if len(diff) == 0 {
+		fmt.Printf(" (none)\n")
+	}
+	for _, line := range diff {
+		fmt.Printf(" %s\n", line)
+	}
+	if len(s.Unsimulated) > 0 {
+		fmt.Printf("\nThe following steps cannot be simulated and were ignored:\n")
+		for _, a := range s.Unsimulated {
+			fmt.Printf(" - %s\n", a.String())
+		}
+	}
+	if len(s.Failures) > 0 {
+		fmt.Printf("\nThe following steps would fail:\n")
+		for _, f := range s.Failures {
+			fmt.Printf(" - %s: %s%s\n", f.Action.String(), f.Err, s.cause(f.Action))
+		}
+	}
+}
+
+// cause describes the chain of actions the action is part of, or returns an empty string when the
+// simulation has no plan tree.
+func (s *Simulation) cause(action AutomationAction) string {
+	if s.Tree == nil {
+		return ""
+	}
+	for _, node := range s.Tree.Steps() {
+		if node.Action == action {
+			return node.Cause()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,321 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/oauth2"
+)
+
+const tokenCacheVersion = 1
+
+// unassigned is the index of a cached token that is not remembered for any account of the
+// environment.
+const unassigned = -1
+
+var errAccountInUse = errors.New("is already logged in")
+
+// TokenCache stores the OAuth2 tokens of authenticated accounts in an encrypted file, so repeated
+// runs can reuse them instead of logging in again. Tokens are keyed by issuer, client ID and the
+// UUID of the account KeyHub reports for the token, and remember which account of the environment
+// they were last used for.
+type TokenCache struct {
+	path       string
+	passphrase string
+	mu         sync.Mutex
+	salt       []byte
+	aead       cipher.AEAD
+	inUse      map[string]int
+}
+
+type cachedToken struct {
+	Issuer      string        `json:"issuer"`
+	ClientID    string        `json:"clientId"`
+	AccountUUID string        `json:"accountUuid"`
+	Username    string        `json:"username"`
+	Index       int           `json:"index"`
+	Token       *oauth2.Token `json:"token"`
+}
+
+type tokenCacheFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewTokenCache returns a cache stored in the file at the given path, encrypted with a key derived
+// from the passphrase. The file is created when the first token is stored.
+func NewTokenCache(path string, passphrase string) (*TokenCache, error) {
+	if passphrase == "" {
+		return nil, errors.New("a passphrase is required to encrypt the token cache")
+	}
+	return &TokenCache{
+		path:       path,
+		passphrase: passphrase,
+		inUse:      make(map[string]int),
+	}, nil
+}
+
+// cipher returns the cipher for the salt. Deriving the key is deliberately slow, so the key is
+// derived once and the salt is reused for every save.
+func (c *TokenCache) cipher(salt []byte) (cipher.AEAD, error) {
+	if c.aead != nil && bytes.Equal(c.salt, salt) {
+		return c.aead, nil
+	}
+	key, err := scrypt.Key([]byte(c.passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	c.salt = salt
+	c.aead = aead
+	return aead, nil
+}
+
+func (c *TokenCache) load() ([]cachedToken, error) {
+	content, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make([]cachedToken, 0), nil
+	} else if err != nil {
+		return nil, err
+	}
+	file := tokenCacheFile{}
+	err = json.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token cache %s: %s", c.path, err)
+	}
+	if file.Version != tokenCacheVersion {
+		return nil, fmt.Errorf("unsupported token cache version %d in %s, expected %d", file.Version, c.path, tokenCacheVersion)
+	}
+	aead, err := c.cipher(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt token cache %s, is the passphrase correct?", c.path)
+	}
+	ret := make([]cachedToken, 0)
+	err = json.Unmarshal(plaintext, &ret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token cache %s: %s", c.path, err)
+	}
+	return ret, nil
+}
+
+func (c *TokenCache) save(tokens []cachedToken) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	salt := c.salt
+	if salt == nil {
+		salt = make([]byte, 16)
+		_, err = io.ReadFull(rand.Reader, salt)
+		if err != nil {
+			return err
+		}
+	}
+	aead, err := c.cipher(salt)
+	if err != nil {
+		return err
+	}
+	file := tokenCacheFile{
+		Version: tokenCacheVersion,
+		Salt:    salt,
+		Nonce:   make([]byte, aead.NonceSize()),
+	}
+	_, err = io.ReadFull(rand.Reader, file.Nonce)
+	if err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, content, 0600)
+}
+
+// candidates returns the cached tokens that may belong to the account with the given index: first
+// the tokens last used for that index, then the tokens not used for any index. Tokens of accounts
+// already authenticated in this run are skipped.
+func (c *TokenCache) candidates(issuer string, clientID string, index int) ([]cachedToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]cachedToken, 0)
+	for _, preferred := range []int{index, unassigned} {
+		for _, t := range tokens {
+			_, used := c.inUse[t.AccountUUID]
+			if t.Issuer == issuer && t.ClientID == clientID && t.Index == preferred && !used {
+				ret = append(ret, t)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// claim marks the account as authenticated for the given index in this run. It fails when the
+// account is already used for another index.
+func (c *TokenCache) claim(accountUUID string, username string, index int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	other, ok := c.inUse[accountUUID]
+	if ok && other != index {
+		return fmt.Errorf("%s %w as account %d, log in as another user for account %d", username, errAccountInUse, other+1, index+1)
+	}
+	c.inUse[accountUUID] = index
+	return nil
+}
+
+// store adds or replaces the token of the account. Other accounts remembered for the same index
+// are kept, but no longer assigned to that index.
+func (c *TokenCache) store(token cachedToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.load()
+	if err != nil {
+		return err
+	}
+	tokens = slices.DeleteFunc(tokens, func(t cachedToken) bool {
+		return t.Issuer == token.Issuer && t.ClientID == token.ClientID && t.AccountUUID == token.AccountUUID
+	})
+	for i := range tokens {
+		if tokens[i].Issuer == token.Issuer && tokens[i].ClientID == token.ClientID && tokens[i].Index == token.Index {
+			tokens[i].Index = unassigned
+		}
+	}
+	tokens = append(tokens, token)
+	return c.save(tokens)
+}
+
+func (c *TokenCache) remove(token cachedToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.load()
+	if err != nil {
+		return err
+	}
+	tokens = slices.DeleteFunc(tokens, func(t cachedToken) bool {
+		return t.Issuer == token.Issuer && t.ClientID == token.ClientID && t.AccountUUID == token.AccountUUID
+	})
+	return c.save(tokens)
+}
+
+// Authenticator returns an authenticator for the account with the given index (0 for account 1).
+// It tries the cached token of the account last authenticated for that index first, then the
+// tokens not used for any account. The identity behind every token is read from KeyHub, so a token
+// is never used for two accounts of the environment. When no valid token is cached, the user logs
+// in with the device authorization flow and the new token is cached.
+func (c *TokenCache) Authenticator(config AuthenticationConfig, index int) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context) (*AuthenticatedAccount, error) {
+		candidates, err := c.candidates(config.Issuer, config.ClientID, index)
+		if err != nil {
+			return nil, err
+		}
+		for _, cached := range candidates {
+			ret, err := c.authenticate(ctx, config, index, cached.Token)
+			if err == nil {
+				if *ret.Account.GetUuid() != cached.AccountUUID {
+					err = c.remove(cached)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return ret, nil
+			}
+			if !errors.Is(err, errAccountInUse) {
+				err = c.remove(cached)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		oauthConfig, err := oauth2Config(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		response, err := oauthConfig.DeviceAuth(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to start device authorization: %s", err)
+		}
+		if response.VerificationURIComplete != "" {
+			fmt.Fprintf(os.Stderr, "To log in account %d, open %s\n", index+1, response.VerificationURIComplete)
+		} else {
+			fmt.Fprintf(os.Stderr, "To log in account %d, open %s and enter code %s\n", index+1, response.VerificationURI, response.UserCode)
+		}
+		token, err := oauthConfig.DeviceAccessToken(ctx, response)
+		if err != nil {
+			return nil, fmt.Errorf("device authorization failed: %s", err)
+		}
+		return c.authenticate(ctx, config, index, token)
+	})
+}
+
+// authenticate logs in with the token, and keeps the cache up to date with the tokens issued
+// while the account is in use. The token is stored under the account KeyHub reports for it.
+func (c *TokenCache) authenticate(ctx context.Context, config AuthenticationConfig, index int, token *oauth2.Token) (*AuthenticatedAccount, error) {
+	entry := cachedToken{
+		Issuer:   config.Issuer,
+		ClientID: config.ClientID,
+		Index:    index,
+	}
+	oauthConfig, err := oauth2Config(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	source := newPersistingTokenSource(oauthConfig.TokenSource(ctx, token), func(token *oauth2.Token) error {
+		entry.Token = token
+		if entry.AccountUUID == "" {
+			return nil
+		}
+		return c.store(entry)
+	})
+	_, err = source.Token()
+	if err != nil {
+		return nil, err
+	}
+	ret, err := NewHTTPClientAuthenticator(oauth2.NewClient(ctx, source), apiURL(config)).Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = c.claim(*ret.Account.GetUuid(), *ret.Account.GetUsername(), index)
+	if err != nil {
+		return nil, err
+	}
+	entry.AccountUUID = *ret.Account.GetUuid()
+	entry.Username = *ret.Account.GetUsername()
+	err = c.store(entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to store token: %s", err)
+	}
+	return ret, nil
+}
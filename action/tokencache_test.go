@@ -0,0 +1,80 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/keyhubtest"
+)
+
+func TestTokenCache(t *testing.T) {
+	ctx := context.Background()
+	server := keyhubtest.NewServer()
+	defer server.Close()
+	admin1 := server.AddAdministrator("admin1")
+	admin2 := server.AddAdministrator("admin2")
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	setup := func(passphrase string) (*action.Environment, error) {
+		cache, err := action.NewTokenCache(path, passphrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := server.Config()
+		config.TokenCache = cache
+		return action.SetupEnvironment(ctx, config)
+	}
+	check := func(env *action.Environment) {
+		t.Helper()
+		if *env.Account1.Account.GetUuid() != admin1.UUID {
+			t.Errorf("account 1 is %s, want %s", *env.Account1.Account.GetUsername(), admin1.Username)
+		}
+		if *env.Account2.Account.GetUuid() != admin2.UUID {
+			t.Errorf("account 2 is %s, want %s", *env.Account2.Account.GetUsername(), admin2.Username)
+		}
+	}
+
+	server.LoginWithDevice(admin1)
+	server.LoginWithDevice(admin2)
+	env, err := setup("secret")
+	if err != nil {
+		t.Fatalf("first run: %s", err)
+	}
+	check(env)
+
+	// No device logins are queued, so the second run must use the cached tokens.
+	env, err = setup("secret")
+	if err != nil {
+		t.Fatalf("second run: %s", err)
+	}
+	check(env)
+
+	_, err = setup("wrong")
+	if err == nil {
+		t.Error("expected an error for a wrong passphrase")
+	}
+}
+
+func TestTokenCacheRejectsSameAccountTwice(t *testing.T) {
+	server := keyhubtest.NewServer()
+	defer server.Close()
+	admin1 := server.AddAdministrator("admin1")
+
+	cache, err := action.NewTokenCache(filepath.Join(t.TempDir(), "tokens.json"), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := server.Config()
+	config.TokenCache = cache
+	server.LoginWithDevice(admin1)
+	server.LoginWithDevice(admin1)
+	_, err = action.SetupEnvironment(context.Background(), config)
+	if err == nil {
+		t.Fatal("expected an error when account 2 logs in as account 1")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package action
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanRole describes why a node is part of the plan tree.
+type PlanRole int
+
+const (
+	// RoleMain is the role of the root of the tree.
+	RoleMain PlanRole = iota
+	// RoleSetup marks a prerequisite collected from the Setup of the parent.
+	RoleSetup
+	// RolePerform marks a child collected from the Perform of the parent.
+	RolePerform
+	// RoleCleanup marks the revert of a setup step, undoing a temporary prerequisite.
+	RoleCleanup
+)
+
+func (r PlanRole) String() string {
+	switch r {
+	case RoleSetup:
+		return "setup"
+	case RolePerform:
+		return "perform"
+	case RoleCleanup:
+		return "cleanup"
+	}
+	return "main"
+}
+
+// PlanNode is a node in the tree of collected actions. Index is the position of the action in the
+// collected list, or -1 when the node does not execute a step of its own, as for sequences and
+// actions eliminated together with their inverse. Inverse links such a pair of eliminated nodes.
+type PlanNode struct {
+	Action   AutomationAction
+	Role     PlanRole
+	Index    int
+	Parent   *PlanNode
+	Children []*PlanNode
+	Inverse  *PlanNode
+}
+
+func newPlanNode(action AutomationAction, role PlanRole, parent *PlanNode) *PlanNode {
+	ret := &PlanNode{
+		Action: action,
+		Role:   role,
+		Index:  -1,
+		Parent: parent,
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, ret)
+	}
+	return ret
+}
+
+// Steps returns the nodes that execute a step, indexed by their position in the collected list.
+func (n *PlanNode) Steps() []*PlanNode {
+	ret := make([]*PlanNode, 0)
+	n.walk(func(node *PlanNode, _ int) {
+		if node.Index >= 0 {
+			ret = append(ret, node)
+		}
+	})
+	steps := make([]*PlanNode, len(ret))
+	for _, node := range ret {
+		steps[node.Index] = node
+	}
+	return steps
+}
+
+// Cause describes the chain of actions this node is part of, one line per ancestor, in the same
+// format as collection errors.
+func (n *PlanNode) Cause() string {
+	var b strings.Builder
+	for node := n; node.Parent != nil; node = node.Parent {
+		fmt.Fprintf(&b, "\n  %s of %s", node.Role, node.Parent.Action.String())
+	}
+	return b.String()
+}
+
+func (n *PlanNode) walk(f func(node *PlanNode, depth int)) {
+	var visit func(node *PlanNode, depth int)
+	visit = func(node *PlanNode, depth int) {
+		f(node, depth)
+		for _, c := range node.Children {
+			visit(c, depth+1)
+		}
+	}
+	visit(n, 0)
+}
+
+func printPlan(root *PlanNode) {
+	fmt.Printf("The following steps will be performed:\n")
+	root.walk(func(node *PlanNode, depth int) {
+		step := "-"
+		if node.Index >= 0 {
+			step = fmt.Sprintf("%d.", node.Index+1)
+		}
+		role := ""
+		if node.Role != RoleMain {
+			role = fmt.Sprintf("[%s] ", node.Role)
+		}
+		fmt.Printf(" %s%s %s%s\n", strings.Repeat("  ", depth), step, role, node.Action.String())
+	})
+}
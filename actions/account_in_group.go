@@ -22,6 +22,9 @@ type accountInGroup struct {
 	group       models.GroupGroupable
 	membership  models.GroupGroupAccountable
 	vaultAccess bool
+	// removed is set when the group is removed after this action, so there is no membership to
+	// revert.
+	removed bool
 }
 
 func NewAccountInGroup(accountUUID string, groupUUID string, rights *models.GroupGroupRights) action.AutomationAction {
@@ -33,6 +36,31 @@ func NewAccountInGroup(accountUUID string, groupUUID string, rights *models.Grou
 	}
 }
 
+// newManagerOfRemovedGroup makes the account a manager of a group that is removed afterwards,
+// without reverting the membership.
+func newManagerOfRemovedGroup(accountUUID string, groupUUID string) action.AutomationAction {
+	return &accountInGroup{
+		accountUUID: accountUUID,
+		groupUUID:   groupUUID,
+		rights:      action.Ptr(models.MANAGER_GROUPGROUPRIGHTS),
+		removed:     true,
+	}
+}
+
+func init() {
+	action.Register("accountInGroup", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 3, 2)
+		if err != nil {
+			return nil, err
+		}
+		rights, err := parseRights(parameters[2])
+		if err != nil {
+			return nil, err
+		}
+		return NewAccountInGroup(*parameters[0], *parameters[1], rights), nil
+	})
+}
+
 func (a *accountInGroup) TypeID() string {
 	return "accountInGroup"
 }
@@ -49,17 +77,21 @@ func (a *accountInGroup) Parameters() []*string {
 	return []*string{&a.accountUUID, &a.groupUUID, rel}
 }
 
-func (a *accountInGroup) Init(ctx context.Context, env *action.Environment) {
-	group, err := action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
-			Uuid:       []string{a.groupUUID},
-			Additional: []string{"accounts"},
-		},
-	}))
+func (a *accountInGroup) References() []action.Reference {
+	return []action.Reference{{Kind: groupWithAccountsKind, UUID: a.groupUUID}, {Kind: accountKind, UUID: a.accountUUID}}
+}
+
+func (a *accountInGroup) Touches() []string {
+	return []string{a.groupUUID, a.accountUUID}
+}
+
+func (a *accountInGroup) Init(ctx context.Context, env *action.Environment) error {
+	group, err := readGroupWithAccounts(ctx, env, a.groupUUID)
 	if err != nil {
-		action.Abort(a, "unable to read group with uuid %s: %s", a.groupUUID, err)
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, err)
 	}
 	a.group = group
+	a.groupUUID = *group.GetUuid()
 
 	for _, m := range group.GetAdditionalObjects().GetAccounts().GetItems() {
 		if *m.GetUuid() == a.accountUUID {
@@ -72,28 +104,44 @@ func (a *accountInGroup) Init(ctx context.Context, env *action.Environment) {
 		a.accountUUID = *env.Account3.Account.GetUuid()
 	}
 	if a.accountUUID != action.Account3UUIDPlaceholder {
-		account, err := action.First[models.AuthAccountable](env.Account1.Client.Account().Get(ctx, &keyhubaccount.AccountRequestBuilderGetRequestConfiguration{
-			QueryParameters: &keyhubaccount.AccountRequestBuilderGetQueryParameters{
-				Uuid: []string{a.accountUUID},
-			},
-		}))
+		account, err := readAccount(ctx, env, a.accountUUID)
 		if err != nil {
-			action.Abort(a, "unable to read account with uuid %s: %s", a.accountUUID, err)
+			return fmt.Errorf("unable to read account with uuid %s: %s", a.accountUUID, err)
 		}
 		a.account = account
 	}
 	if a.membership != nil {
-		memberships, err := env.Account1.Client.Account().ByAccountidInt64(*action.Self(a.account).GetId()).Group().Get(ctx, &keyhubaccount.ItemGroupRequestBuilderGetRequestConfiguration{
+		vaultAccess, err := hasVaultAccess(ctx, env, a.account, a.group)
+		if err != nil {
+			return fmt.Errorf("unable to read group memberships for account with uuid %s: %s", a.accountUUID, err)
+		}
+		a.vaultAccess = vaultAccess
+	}
+	return nil
+}
+
+func hasVaultAccess(ctx context.Context, env *action.Environment, account models.AuthAccountable, group models.GroupGroupable) (bool, error) {
+	accountID, err := action.SelfID(account)
+	if err != nil {
+		return false, err
+	}
+	groupID, err := action.SelfID(group)
+	if err != nil {
+		return false, err
+	}
+	// Keyed by the group, so the entry is invalidated by the actions touching the group.
+	return action.Cached(env, fmt.Sprintf("vaultaccess/%d", accountID), *group.GetUuid(), func() (bool, error) {
+		memberships, err := env.Account1.Client.Account().ByAccountidInt64(accountID).Group().Get(ctx, &keyhubaccount.ItemGroupRequestBuilderGetRequestConfiguration{
 			QueryParameters: &keyhubaccount.ItemGroupRequestBuilderGetQueryParameters{
-				Group:       []int64{*action.Self(group).GetId()},
+				Group:       []int64{groupID},
 				VaultAccess: []bool{true},
 			},
 		})
 		if err != nil {
-			action.Abort(a, "unable to read group memberships for account with uuid %s: %s", a.accountUUID, action.KeyHubError(err))
+			return false, action.KeyHubError(err)
 		}
-		a.vaultAccess = len(memberships.GetItems()) > 0
-	}
+		return len(memberships.GetItems()) > 0, nil
+	})
 }
 
 func (a *accountInGroup) IsSatisfied() bool {
@@ -120,6 +168,15 @@ func (a *accountInGroup) AllowGlobalOptimization() bool {
 }
 
 func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) error {
+	groupID, err := action.SelfID(a.group)
+	if err != nil {
+		return fmt.Errorf("invalid group in '%s': %s", a.String(), err)
+	}
+	accountID, err := action.SelfID(a.account)
+	if err != nil {
+		return fmt.Errorf("invalid account in '%s': %s", a.String(), err)
+	}
+
 	vaultAccessGiven := false
 	if a.membership == nil || (a.rights != nil && *a.rights == models.MANAGER_GROUPGROUPRIGHTS) {
 		auth1 := env.Account1
@@ -144,7 +201,11 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 		addAdmin.SetPrivateKey(&env.VaultRecoveryKey)
 		addAdmin.SetStatus(action.Ptr(models.ALLOWED_REQUESTMODIFICATIONREQUESTSTATUS))
 		addAdmin.SetFeedback(action.Ptr("automation accountInGroup"))
-		_, err = auth2.Client.Request().ByRequestidInt64(*action.Self(addAdmin).GetId()).Put(ctx, addAdmin, nil)
+		addAdminID, err := action.SelfID(addAdmin)
+		if err != nil {
+			return fmt.Errorf("invalid request in '%s': %s", a.String(), err)
+		}
+		_, err = auth2.Client.Request().ByRequestidInt64(addAdminID).Put(ctx, addAdmin, nil)
 		if err != nil {
 			return fmt.Errorf("cannot confirm to add manager to group in '%s': %s", a.String(), action.KeyHubError(err))
 		}
@@ -157,7 +218,7 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 				auth = *env.Account2
 			}
 
-			member, err := action.First[models.GroupGroupAccountable](auth.Client.Group().ByGroupidInt64(*action.Self(a.group).GetId()).Account().Get(ctx, &keyhubgroup.ItemAccountRequestBuilderGetRequestConfiguration{
+			member, err := action.First[models.GroupGroupAccountable](auth.Client.Group().ByGroupidInt64(groupID).Account().Get(ctx, &keyhubgroup.ItemAccountRequestBuilderGetRequestConfiguration{
 				QueryParameters: &keyhubgroup.ItemAccountRequestBuilderGetQueryParameters{
 					Account: []int64{*a.account.GetLinks()[0].GetId()},
 				},
@@ -166,7 +227,15 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 				return fmt.Errorf("cannot fetch group membership in '%s': %s", a.String(), action.KeyHubError(err))
 			}
 			member.SetRights(action.Ptr(models.NORMAL_GROUPGROUPRIGHTS))
-			_, err = auth.Client.Group().ByGroupidInt64(*action.Self(member).GetId()).Account().ByAccountidInt64(*action.Koppeling(member).GetId()).Put(ctx, member, nil)
+			memberGroupID, err := action.SelfID(member)
+			if err != nil {
+				return fmt.Errorf("invalid group membership in '%s': %s", a.String(), err)
+			}
+			memberAccountID, err := action.KoppelingID(member)
+			if err != nil {
+				return fmt.Errorf("invalid group membership in '%s': %s", a.String(), err)
+			}
+			_, err = auth.Client.Group().ByGroupidInt64(memberGroupID).Account().ByAccountidInt64(memberAccountID).Put(ctx, member, nil)
 			if err != nil {
 				return fmt.Errorf("cannot convert user to normal in '%s': %s", a.String(), action.KeyHubError(err))
 			}
@@ -178,8 +247,8 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 					models.ADD_REQUESTUPDATEGROUPMEMBERSHIPTYPE.String(),
 					models.MODIFY_REQUESTUPDATEGROUPMEMBERSHIPTYPE.String()},
 				Status:          []string{models.REQUESTED_REQUESTMODIFICATIONREQUESTSTATUS.String()},
-				Group:           []int64{*action.Self(a.group).GetId()},
-				AccountToUpdate: []int64{*action.Self(a.account).GetId()},
+				Group:           []int64{groupID},
+				AccountToUpdate: []int64{accountID},
 			},
 		}))
 		if err != nil {
@@ -187,7 +256,11 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 		}
 		request.SetStatus(action.Ptr(models.ALLOWED_REQUESTMODIFICATIONREQUESTSTATUS))
 		request.SetFeedback(action.Ptr("automation accountInGroup"))
-		_, err = env.Account3.Client.Request().ByRequestidInt64(*action.Self(request).GetId()).Put(ctx, request, nil)
+		requestID, err := action.SelfID(request)
+		if err != nil {
+			return fmt.Errorf("invalid request in '%s': %s", a.String(), err)
+		}
+		_, err = env.Account3.Client.Request().ByRequestidInt64(requestID).Put(ctx, request, nil)
 		if err != nil {
 			return fmt.Errorf("cannot confirm to update group membership in '%s': %s", a.String(), action.KeyHubError(err))
 		}
@@ -198,7 +271,7 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 				auth = *env.Account2
 			}
 
-			member, err := action.First[models.GroupGroupAccountable](auth.Client.Group().ByGroupidInt64(*action.Self(a.group).GetId()).Account().Get(ctx, &keyhubgroup.ItemAccountRequestBuilderGetRequestConfiguration{
+			member, err := action.First[models.GroupGroupAccountable](auth.Client.Group().ByGroupidInt64(groupID).Account().Get(ctx, &keyhubgroup.ItemAccountRequestBuilderGetRequestConfiguration{
 				QueryParameters: &keyhubgroup.ItemAccountRequestBuilderGetQueryParameters{
 					Account: []int64{*a.account.GetLinks()[0].GetId()},
 				},
@@ -221,16 +294,11 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 		}
 	}
 	if !vaultAccessGiven {
-		memberships, err := env.Account1.Client.Account().ByAccountidInt64(*action.Self(a.account).GetId()).Group().Get(ctx, &keyhubaccount.ItemGroupRequestBuilderGetRequestConfiguration{
-			QueryParameters: &keyhubaccount.ItemGroupRequestBuilderGetQueryParameters{
-				VaultAccess: []bool{true},
-				Group:       []int64{*action.Self(a.group).GetId()},
-			},
-		})
+		vaultAccess, err := hasVaultAccess(ctx, env, a.account, a.group)
 		if err != nil {
-			action.Abort(a, "unable to read group memberships for account with uuid %s: %s", a.accountUUID, action.KeyHubError(err))
+			return fmt.Errorf("unable to read group memberships for account with uuid %s: %s", a.accountUUID, err)
 		}
-		if len(memberships.GetItems()) == 0 {
+		if !vaultAccess {
 			auth := env.Account1
 			if a.accountUUID == *env.Account1.Account.GetUuid() {
 				auth = env.Account2
@@ -239,7 +307,7 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 			recovery := models.NewVaultVaultRecovery()
 			recovery.SetAccount(a.account)
 			recovery.SetPrivateKey(&env.VaultRecoveryKey)
-			err := auth.Client.Group().ByGroupidInt64(*action.Self(a.group).GetId()).Vault().Recover().Post(ctx, recovery, nil)
+			err := auth.Client.Group().ByGroupidInt64(groupID).Vault().Recover().Post(ctx, recovery, nil)
 			if err != nil {
 				return fmt.Errorf("cannot recover vault access in '%s': %s", a.String(), action.KeyHubError(err))
 			}
@@ -248,6 +316,41 @@ func (a *accountInGroup) Execute(ctx context.Context, env *action.Environment) e
 	return nil
 }
 
+func (a *accountInGroup) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	model.ObserveAccount(a.account)
+}
+
+func (a *accountInGroup) Simulate(env *action.Environment, model *action.Model) error {
+	rights, member := model.Rights(a.groupUUID, a.accountUUID)
+	if !member {
+		rights = models.MANAGER_GROUPGROUPRIGHTS
+	}
+	if a.rights != nil {
+		rights = *a.rights
+	}
+	authorizing := model.AuthorizingGroup(a.groupUUID, models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE)
+	if authorizing != "" {
+		if !model.IsMember(authorizing, uuidOf(env.Account3)) {
+			return fmt.Errorf("%s is not a member of %s, authorizing memberships of %s",
+				model.Name(uuidOf(env.Account3)), model.Name(authorizing), model.Name(a.groupUUID))
+		}
+	} else if rights == models.NORMAL_GROUPGROUPRIGHTS {
+		auth := uuidOf(env.Account1)
+		if a.accountUUID == uuidOf(env.Account2) {
+			auth = uuidOf(env.Account2)
+		}
+		if auth != a.accountUUID {
+			err := requireManager(model, a.groupUUID, auth)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	model.SetRights(a.groupUUID, a.accountUUID, rights)
+	return nil
+}
+
 func (a *accountInGroup) Setup(env *action.Environment) []action.AutomationAction {
 	ret := make([]action.AutomationAction, 0)
 	if a.rights != nil && *a.rights == models.NORMAL_GROUPGROUPRIGHTS {
@@ -268,6 +371,9 @@ func (a *accountInGroup) Perform(env *action.Environment) []action.AutomationAct
 }
 
 func (a *accountInGroup) Revert() action.AutomationAction {
+	if a.removed {
+		return nil
+	}
 	if a.membership == nil {
 		return NewAccountNotInGroup(a.accountUUID, a.groupUUID)
 	}
@@ -0,0 +1,121 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func TestAccountInGroup(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "add normal member",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				user := f.AddAccount("user")
+				team := f.AddGroup("Team")
+				return actions.NewAccountInGroup(user.UUID, team.UUID, action.Ptr(models.NORMAL_GROUPGROUPRIGHTS)), func(t *testing.T) {
+					f.checkRights(t, team, user, action.Ptr(models.NORMAL_GROUPGROUPRIGHTS))
+					if !f.HasVaultAccess(team, user) {
+						t.Errorf("user has no access to the vault of Team")
+					}
+					f.checkRights(t, team, f.admin1, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Team' as manager",
+				"Add user to 'Team' as normal member",
+				"Remove admin1 from 'Team'",
+			},
+		},
+		{
+			name: "already a member",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				user := f.AddAccount("user")
+				team := f.AddGroup("Team")
+				f.AddMember(team, user, models.NORMAL_GROUPGROUPRIGHTS)
+				return actions.NewAccountInGroup(user.UUID, team.UUID, action.Ptr(models.NORMAL_GROUPGROUPRIGHTS)), func(t *testing.T) {
+					f.checkRights(t, team, user, action.Ptr(models.NORMAL_GROUPGROUPRIGHTS))
+				}
+			},
+			plan: []string{},
+		},
+		{
+			name: "approved by the authorizing group",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				user := f.AddAccount("user")
+				team := f.AddGroup("Team")
+				approvers := f.AddGroup("Approvers")
+				f.SetAuthorizingGroup(team, models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE, approvers)
+				return actions.NewAccountInGroup(user.UUID, team.UUID, nil), func(t *testing.T) {
+					f.checkRights(t, team, user, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS))
+					f.checkRights(t, approvers, f.admin1, nil)
+					f.checkRights(t, approvers, f.admin3, nil)
+					approved := false
+					for _, r := range f.Requests() {
+						if update, ok := r.(*models.RequestUpdateGroupMembershipRequest); ok {
+							approved = *update.GetStatus() == models.ALLOWED_REQUESTMODIFICATIONREQUESTSTATUS
+						}
+					}
+					if !approved {
+						t.Errorf("membership of user was not approved by Approvers")
+					}
+				}
+			},
+			plan: []string{
+				"Add admin3 to 'Approvers'",
+				"Add user to 'Team'",
+				"Add admin1 to 'Approvers' as manager",
+				"Remove admin3 from 'Approvers'",
+				"Remove admin1 from 'Approvers'",
+			},
+		},
+	})
+}
+
+func TestAccountNotInGroup(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "remove member",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				user := f.AddAccount("user")
+				team := f.AddGroup("Team")
+				f.AddMember(team, user, models.NORMAL_GROUPGROUPRIGHTS)
+				return actions.NewAccountNotInGroup(user.UUID, team.UUID), func(t *testing.T) {
+					f.checkRights(t, team, user, nil)
+					f.checkRights(t, team, f.admin1, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Team' as manager",
+				"Remove user from 'Team'",
+				"Remove admin1 from 'Team'",
+			},
+		},
+		{
+			name: "not a member",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				user := f.AddAccount("user")
+				team := f.AddGroup("Team")
+				return actions.NewAccountNotInGroup(user.UUID, team.UUID), func(t *testing.T) {
+					f.checkRights(t, team, user, nil)
+				}
+			},
+			plan: []string{},
+		},
+	})
+}
+
+func TestAccountNotInGroupUnknownGroup(t *testing.T) {
+	f := newFixture(t)
+	user := f.AddAccount("user")
+	_, err := action.Collect(context.Background(), actions.NewAccountNotInGroup(user.UUID, "00000000-0000-0000-0000-000000000000"), f.env, nopStepper{})
+	if err == nil {
+		t.Fatal("expected an error for an unknown group")
+	}
+}
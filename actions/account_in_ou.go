@@ -8,7 +8,6 @@ import (
 	"fmt"
 
 	"github.com/topicuskeyhub/automation-framework/action"
-	keyhubaccount "github.com/topicuskeyhub/sdk-go/account"
 	"github.com/topicuskeyhub/sdk-go/models"
 	keyhuborganizationalunit "github.com/topicuskeyhub/sdk-go/organizationalunit"
 )
@@ -28,6 +27,16 @@ func NewAccountInOU(accountUUID string, orgUnitUUID string) action.AutomationAct
 	}
 }
 
+func init() {
+	action.Register("accountInOU", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewAccountInOU(*parameters[0], *parameters[1]), nil
+	})
+}
+
 func (a *accountInOU) TypeID() string {
 	return "accountInOU"
 }
@@ -36,39 +45,46 @@ func (a *accountInOU) Parameters() []*string {
 	return []*string{&a.accountUUID, &a.orgUnitUUID}
 }
 
-func (a *accountInOU) Init(ctx context.Context, env *action.Environment) {
-	account, err := action.First[models.AuthAccountable](
-		env.Account1.Client.Account().Get(ctx, &keyhubaccount.AccountRequestBuilderGetRequestConfiguration{
-			QueryParameters: &keyhubaccount.AccountRequestBuilderGetQueryParameters{
-				Uuid: []string{a.accountUUID},
-			},
-		}))
+func (a *accountInOU) References() []action.Reference {
+	return []action.Reference{{Kind: accountKind, UUID: a.accountUUID}, {Kind: orgUnitKind, UUID: a.orgUnitUUID}}
+}
+
+func (a *accountInOU) Touches() []string {
+	return []string{a.accountUUID, a.orgUnitUUID}
+}
+
+func (a *accountInOU) Init(ctx context.Context, env *action.Environment) error {
+	account, err := readAccount(ctx, env, a.accountUUID)
 	if err != nil {
-		action.Abort(a, "unable to read account with UUID %s: %s", a.accountUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read account with UUID %s: %s", a.accountUUID, action.KeyHubError(err))
 	}
-	orgUnit, err := action.First[models.OrganizationOrganizationalUnitable](
-		env.Account1.Client.Organizationalunit().Get(ctx, &keyhuborganizationalunit.OrganizationalunitRequestBuilderGetRequestConfiguration{
-			QueryParameters: &keyhuborganizationalunit.OrganizationalunitRequestBuilderGetQueryParameters{
-				Uuid: []string{a.orgUnitUUID},
-			},
-		}))
+	orgUnit, err := readOrganizationalUnit(ctx, env, a.orgUnitUUID)
 	if err != nil {
-		action.Abort(a, "unable to read organisational unit with UUID %s: %s", a.orgUnitUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read organisational unit with UUID %s: %s", a.orgUnitUUID, action.KeyHubError(err))
 	}
 
 	a.account = account
 	a.orgUnit = orgUnit
 
-	orgUnitAccounts, err := env.Account1.Client.Organizationalunit().ByOrganizationalunitidInt64(*action.Self(orgUnit).GetId()).
+	orgUnitID, err := action.SelfID(orgUnit)
+	if err != nil {
+		return fmt.Errorf("invalid organisational unit with UUID %s: %s", a.orgUnitUUID, err)
+	}
+	accountID, err := action.SelfID(account)
+	if err != nil {
+		return fmt.Errorf("invalid account with UUID %s: %s", a.accountUUID, err)
+	}
+	orgUnitAccounts, err := env.Account1.Client.Organizationalunit().ByOrganizationalunitidInt64(orgUnitID).
 		Account().Get(ctx, &keyhuborganizationalunit.ItemAccountRequestBuilderGetRequestConfiguration{
 		QueryParameters: &keyhuborganizationalunit.ItemAccountRequestBuilderGetQueryParameters{
-			Account: []int64{*action.Self(account).GetId()},
+			Account: []int64{accountID},
 		},
 	})
 	if err != nil {
-		action.Abort(a, "unable to read organisational unit memberships for account %s, unit %s: %s", a.accountUUID, a.orgUnitUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read organisational unit memberships for account %s, unit %s: %s", a.accountUUID, a.orgUnitUUID, action.KeyHubError(err))
 	}
 	a.member = len(orgUnitAccounts.GetItems()) == 1
+	return nil
 }
 
 func (a *accountInOU) IsSatisfied() bool {
@@ -84,13 +100,21 @@ func (a *accountInOU) AllowGlobalOptimization() bool {
 }
 
 func (a *accountInOU) Execute(ctx context.Context, env *action.Environment) error {
+	accountSelf, err := action.Self(a.account)
+	if err != nil {
+		return fmt.Errorf("invalid account in '%s': %s", a.String(), err)
+	}
+	orgUnitID, err := action.SelfID(a.orgUnit)
+	if err != nil {
+		return fmt.Errorf("invalid organisational unit in '%s': %s", a.String(), err)
+	}
 	newOrgUnitAccount := models.NewOrganizationOrganizationalUnitAccount()
-	newOrgUnitAccount.SetLinks([]models.RestLinkable{action.Self(a.account)})
+	newOrgUnitAccount.SetLinks([]models.RestLinkable{accountSelf})
 	wrapper := models.NewOrganizationOrganizationalUnitAccountLinkableWrapper()
 	wrapper.SetItems([]models.OrganizationOrganizationalUnitAccountable{newOrgUnitAccount})
 
-	_, err := action.First[models.OrganizationOrganizationalUnitAccountable](
-		env.Account1.Client.Organizationalunit().ByOrganizationalunitidInt64(*action.Self(a.orgUnit).GetId()).
+	_, err = action.First[models.OrganizationOrganizationalUnitAccountable](
+		env.Account1.Client.Organizationalunit().ByOrganizationalunitidInt64(orgUnitID).
 			Account().Post(ctx, wrapper, nil))
 	if err != nil {
 		return fmt.Errorf("cannot add account to organisational unit in '%s': %s", a.String(), action.KeyHubError(err))
@@ -98,6 +122,21 @@ func (a *accountInOU) Execute(ctx context.Context, env *action.Environment) erro
 	return nil
 }
 
+func (a *accountInOU) Observe(model *action.Model) {
+	model.ObserveAccount(a.account)
+	model.SetName(a.orgUnitUUID, *a.orgUnit.GetName())
+	model.SetInOrganizationalUnit(a.orgUnitUUID, a.accountUUID, a.member)
+}
+
+func (a *accountInOU) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, *a.orgUnit.GetOwner().GetUuid(), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetInOrganizationalUnit(a.orgUnitUUID, a.accountUUID, true)
+	return nil
+}
+
 func (a *accountInOU) Setup(env *action.Environment) []action.AutomationAction {
 	ret := make([]action.AutomationAction, 0)
 	ret = append(ret, NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.orgUnit.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)))
@@ -109,7 +148,7 @@ func (a *accountInOU) Perform(env *action.Environment) []action.AutomationAction
 }
 
 func (a *accountInOU) Revert() action.AutomationAction {
-	return nil
+	return NewAccountNotInOU(a.accountUUID, a.orgUnitUUID)
 }
 
 func (a *accountInOU) Progress() string {
@@ -8,7 +8,6 @@ import (
 	"fmt"
 
 	"github.com/topicuskeyhub/automation-framework/action"
-	keyhubaccount "github.com/topicuskeyhub/sdk-go/account"
 	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
 	"github.com/topicuskeyhub/sdk-go/models"
 )
@@ -28,6 +27,16 @@ func NewAccountNotInGroup(accountUUID string, groupUUID string) action.Automatio
 	}
 }
 
+func init() {
+	action.Register("accountNotInGroup", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewAccountNotInGroup(*parameters[0], *parameters[1]), nil
+	})
+}
+
 func (a *accountNotInGroup) TypeID() string {
 	return "accountNotInGroup"
 }
@@ -36,17 +45,21 @@ func (a *accountNotInGroup) Parameters() []*string {
 	return []*string{&a.accountUUID, &a.groupUUID}
 }
 
-func (a *accountNotInGroup) Init(ctx context.Context, env *action.Environment) {
-	group, err := action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
-			Uuid:       []string{a.groupUUID},
-			Additional: []string{"accounts"},
-		},
-	}))
+func (a *accountNotInGroup) References() []action.Reference {
+	return []action.Reference{{Kind: groupWithAccountsKind, UUID: a.groupUUID}, {Kind: accountKind, UUID: a.accountUUID}}
+}
+
+func (a *accountNotInGroup) Touches() []string {
+	return []string{a.groupUUID, a.accountUUID}
+}
+
+func (a *accountNotInGroup) Init(ctx context.Context, env *action.Environment) error {
+	group, err := readGroupWithAccounts(ctx, env, a.groupUUID)
 	if err != nil {
-		action.Abort(a, "unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
 	}
 	a.group = group
+	a.groupUUID = *group.GetUuid()
 
 	if a.accountUUID == action.Account3UUIDPlaceholder && env.Account3 != nil {
 		a.accountUUID = *env.Account3.Account.GetUuid()
@@ -59,16 +72,13 @@ func (a *accountNotInGroup) Init(ctx context.Context, env *action.Environment) {
 			}
 		}
 
-		account, err := action.First[models.AuthAccountable](env.Account1.Client.Account().Get(ctx, &keyhubaccount.AccountRequestBuilderGetRequestConfiguration{
-			QueryParameters: &keyhubaccount.AccountRequestBuilderGetQueryParameters{
-				Uuid: []string{a.accountUUID},
-			},
-		}))
+		account, err := readAccount(ctx, env, a.accountUUID)
 		if err != nil {
-			action.Abort(a, "unable to read account with uuid %s: %s", a.accountUUID, action.KeyHubError(err))
+			return fmt.Errorf("unable to read account with uuid %s: %s", a.accountUUID, action.KeyHubError(err))
 		}
 		a.account = account
 	}
+	return nil
 }
 
 func (a *accountNotInGroup) IsSatisfied() bool {
@@ -91,7 +101,11 @@ func (a *accountNotInGroup) Execute(ctx context.Context, env *action.Environment
 		auth = *env.Account3
 	}
 
-	member, err := action.First[models.GroupGroupAccountable](auth.Client.Group().ByGroupidInt64(*action.Self(a.group).GetId()).Account().Get(ctx, &keyhubgroup.ItemAccountRequestBuilderGetRequestConfiguration{
+	groupID, err := action.SelfID(a.group)
+	if err != nil {
+		return fmt.Errorf("invalid group in '%s': %s", a.String(), err)
+	}
+	member, err := action.First[models.GroupGroupAccountable](auth.Client.Group().ByGroupidInt64(groupID).Account().Get(ctx, &keyhubgroup.ItemAccountRequestBuilderGetRequestConfiguration{
 		QueryParameters: &keyhubgroup.ItemAccountRequestBuilderGetQueryParameters{
 			Account: []int64{*a.account.GetLinks()[0].GetId()},
 		},
@@ -99,13 +113,37 @@ func (a *accountNotInGroup) Execute(ctx context.Context, env *action.Environment
 	if err != nil {
 		return fmt.Errorf("cannot fetch group membership in '%s': %s", a.String(), action.KeyHubError(err))
 	}
-	err = auth.Client.Group().ByGroupidInt64(*action.Self(a.group).GetId()).Account().ByAccountidInt64(*action.Koppeling(member).GetId()).Delete(ctx, nil)
+	memberAccountID, err := action.KoppelingID(member)
+	if err != nil {
+		return fmt.Errorf("invalid group membership in '%s': %s", a.String(), err)
+	}
+	err = auth.Client.Group().ByGroupidInt64(groupID).Account().ByAccountidInt64(memberAccountID).Delete(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("cannot remove user from group in '%s': %s", a.String(), action.KeyHubError(err))
 	}
 	return nil
 }
 
+func (a *accountNotInGroup) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	model.ObserveAccount(a.account)
+}
+
+func (a *accountNotInGroup) Simulate(env *action.Environment, model *action.Model) error {
+	if !model.IsMember(a.groupUUID, a.accountUUID) {
+		return fmt.Errorf("%s is not a member of %s", model.Name(a.accountUUID), model.Name(a.groupUUID))
+	}
+	self := a.accountUUID == uuidOf(env.Account1) || a.accountUUID == uuidOf(env.Account2) || a.accountUUID == uuidOf(env.Account3)
+	if !self {
+		err := requireManager(model, a.groupUUID, uuidOf(env.Account1))
+		if err != nil {
+			return err
+		}
+	}
+	model.RemoveMember(a.groupUUID, a.accountUUID)
+	return nil
+}
+
 func (a *accountNotInGroup) Setup(env *action.Environment) []action.AutomationAction {
 	account1UUID := *env.Account1.Account.GetUuid()
 	account2UUID := *env.Account2.Account.GetUuid()
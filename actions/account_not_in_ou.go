@@ -0,0 +1,169 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+	keyhuborganizationalunit "github.com/topicuskeyhub/sdk-go/organizationalunit"
+)
+
+type accountNotInOU struct {
+	accountUUID string
+	orgUnitUUID string
+	member      bool
+	account     models.AuthAccountable
+	orgUnit     models.OrganizationOrganizationalUnitable
+}
+
+func NewAccountNotInOU(accountUUID string, orgUnitUUID string) action.AutomationAction {
+	return &accountNotInOU{
+		accountUUID: accountUUID,
+		orgUnitUUID: orgUnitUUID,
+	}
+}
+
+func init() {
+	action.Register("accountNotInOU", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewAccountNotInOU(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *accountNotInOU) TypeID() string {
+	return "accountNotInOU"
+}
+
+func (a *accountNotInOU) Parameters() []*string {
+	return []*string{&a.accountUUID, &a.orgUnitUUID}
+}
+
+func (a *accountNotInOU) References() []action.Reference {
+	return []action.Reference{{Kind: accountKind, UUID: a.accountUUID}, {Kind: orgUnitKind, UUID: a.orgUnitUUID}}
+}
+
+func (a *accountNotInOU) Touches() []string {
+	return []string{a.accountUUID, a.orgUnitUUID}
+}
+
+func (a *accountNotInOU) Init(ctx context.Context, env *action.Environment) error {
+	account, err := readAccount(ctx, env, a.accountUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read account with UUID %s: %s", a.accountUUID, action.KeyHubError(err))
+	}
+	orgUnit, err := readOrganizationalUnit(ctx, env, a.orgUnitUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read organisational unit with UUID %s: %s", a.orgUnitUUID, action.KeyHubError(err))
+	}
+
+	a.account = account
+	a.orgUnit = orgUnit
+
+	orgUnitID, err := action.SelfID(orgUnit)
+	if err != nil {
+		return fmt.Errorf("invalid organisational unit with UUID %s: %s", a.orgUnitUUID, err)
+	}
+	accountID, err := action.SelfID(account)
+	if err != nil {
+		return fmt.Errorf("invalid account with UUID %s: %s", a.accountUUID, err)
+	}
+	orgUnitAccounts, err := env.Account1.Client.Organizationalunit().ByOrganizationalunitidInt64(orgUnitID).
+		Account().Get(ctx, &keyhuborganizationalunit.ItemAccountRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhuborganizationalunit.ItemAccountRequestBuilderGetQueryParameters{
+			Account: []int64{accountID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to read organisational unit memberships for account %s, unit %s: %s", a.accountUUID, a.orgUnitUUID, action.KeyHubError(err))
+	}
+	a.member = len(orgUnitAccounts.GetItems()) == 1
+	return nil
+}
+
+func (a *accountNotInOU) IsSatisfied() bool {
+	return !a.member
+}
+
+func (a *accountNotInOU) Requires3() bool {
+	return false
+}
+
+func (a *accountNotInOU) AllowGlobalOptimization() bool {
+	return true
+}
+
+func (a *accountNotInOU) Execute(ctx context.Context, env *action.Environment) error {
+	accountID, err := action.SelfID(a.account)
+	if err != nil {
+		return fmt.Errorf("invalid account in '%s': %s", a.String(), err)
+	}
+	orgUnitID, err := action.SelfID(a.orgUnit)
+	if err != nil {
+		return fmt.Errorf("invalid organisational unit in '%s': %s", a.String(), err)
+	}
+	err = env.Account1.Client.Organizationalunit().ByOrganizationalunitidInt64(orgUnitID).
+		Account().ByAccountidInt64(accountID).Delete(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot remove account from organisational unit in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *accountNotInOU) Observe(model *action.Model) {
+	model.ObserveAccount(a.account)
+	model.SetName(a.orgUnitUUID, *a.orgUnit.GetName())
+	model.SetInOrganizationalUnit(a.orgUnitUUID, a.accountUUID, a.member)
+}
+
+func (a *accountNotInOU) Simulate(env *action.Environment, model *action.Model) error {
+	if !model.InOrganizationalUnit(a.orgUnitUUID, a.accountUUID) {
+		return fmt.Errorf("%s is not in organizational unit %s", model.Name(a.accountUUID), model.Name(a.orgUnitUUID))
+	}
+	err := requireManager(model, *a.orgUnit.GetOwner().GetUuid(), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetInOrganizationalUnit(a.orgUnitUUID, a.accountUUID, false)
+	return nil
+}
+
+func (a *accountNotInOU) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.orgUnit.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (a *accountNotInOU) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *accountNotInOU) Revert() action.AutomationAction {
+	return NewAccountInOU(a.accountUUID, a.orgUnitUUID)
+}
+
+func (a *accountNotInOU) Progress() string {
+	accountName := a.accountUUID
+	if a.account != nil {
+		accountName = *a.account.GetUsername()
+	}
+	return fmt.Sprintf("Removing %s", accountName)
+}
+
+func (a *accountNotInOU) String() string {
+	accountName := a.accountUUID
+	if a.account != nil {
+		accountName = *a.account.GetUsername()
+	}
+	ouName := a.orgUnitUUID
+	if a.orgUnit != nil {
+		ouName = *a.orgUnit.GetName()
+	}
+	return fmt.Sprintf("Remove %s from '%s'", accountName, ouName)
+}
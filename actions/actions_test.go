@@ -0,0 +1,114 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/keyhubtest"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// fixture is a fake KeyHub with three administrators, authenticated as Account1, Account2 and
+// Account3 of env.
+type fixture struct {
+	*keyhubtest.Server
+	admin1 *keyhubtest.Account
+	admin2 *keyhubtest.Account
+	admin3 *keyhubtest.Account
+	env    *action.Environment
+}
+
+func newFixture(t *testing.T) *fixture {
+	server := keyhubtest.NewServer()
+	t.Cleanup(server.Close)
+	f := &fixture{
+		Server: server,
+		admin1: server.AddAdministrator("admin1"),
+		admin2: server.AddAdministrator("admin2"),
+		admin3: server.AddAdministrator("admin3"),
+	}
+	ctx := context.Background()
+	config := server.Config(f.admin1, f.admin2, f.admin3)
+	env, err := action.SetupEnvironment(ctx, config)
+	if err != nil {
+		t.Fatalf("setup environment: %s", err)
+	}
+	err = action.AuthenticateAccount3(ctx, config, env)
+	if err != nil {
+		t.Fatalf("authenticate account 3: %s", err)
+	}
+	f.env = env
+	return f
+}
+
+// checkRights fails the test when the rights of the account in the group differ from want, where
+// nil means the account is not a member.
+func (f *fixture) checkRights(t *testing.T, group *keyhubtest.Group, account *keyhubtest.Account, want *models.GroupGroupRights) {
+	t.Helper()
+	got := f.Rights(group, account)
+	switch {
+	case got == nil && want == nil:
+	case got == nil:
+		t.Errorf("%s is not a member of %s, want %s", account.Username, group.Name, want.String())
+	case want == nil:
+		t.Errorf("%s is %s of %s, want no membership", account.Username, got.String(), group.Name)
+	case *got != *want:
+		t.Errorf("%s is %s of %s, want %s", account.Username, got.String(), group.Name, want.String())
+	}
+}
+
+type nopStepper struct{}
+
+func (nopStepper) Step()              {}
+func (nopStepper) AddSteps(num int64) {}
+func (nopStepper) Done()              {}
+
+// actionTest describes a test of a single action against a fresh fixture. The setup adds the
+// fixtures and returns the action and a check of the state after executing the plan, plan is the
+// expected plan as returned by Collect.
+type actionTest struct {
+	name  string
+	setup func(f *fixture) (action.AutomationAction, func(t *testing.T))
+	plan  []string
+}
+
+// runActionTests collects the action of every test, compares the plan and executes its steps
+// like the runner does, before checking the resulting state.
+func runActionTests(t *testing.T, tests []actionTest) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			a, check := tt.setup(f)
+			ctx := context.Background()
+			steps, err := action.Collect(ctx, a, f.env, nopStepper{})
+			if err != nil {
+				t.Fatalf("collect: %s", err)
+			}
+			plan := make([]string, len(steps))
+			for i, step := range steps {
+				plan[i] = step.String()
+			}
+			if !slices.Equal(plan, tt.plan) {
+				t.Fatalf("collected plan:\n  %s\nwant:\n  %s", strings.Join(plan, "\n  "), strings.Join(tt.plan, "\n  "))
+			}
+			for _, step := range steps {
+				f.env.Cache.Invalidate()
+				err = step.Init(ctx, f.env)
+				if err != nil {
+					t.Fatalf("init '%s': %s", step.String(), err)
+				}
+				err = step.Execute(ctx, f.env)
+				if err != nil {
+					t.Fatalf("execute '%s': %s", step.String(), err)
+				}
+			}
+			check(t)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubclient "github.com/topicuskeyhub/sdk-go/client"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+type clientOwnedByGroup struct {
+	clientUUID string
+	groupUUID  string
+	client     models.ClientClientApplicationable
+	group      models.GroupGroupable
+}
+
+func NewClientOwnedByGroup(clientUUID string, groupUUID string) action.AutomationAction {
+	return &clientOwnedByGroup{
+		clientUUID: clientUUID,
+		groupUUID:  groupUUID,
+	}
+}
+
+func init() {
+	action.Register("clientOwnedByGroup", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewClientOwnedByGroup(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *clientOwnedByGroup) TypeID() string {
+	return "clientOwnedByGroup"
+}
+
+func (a *clientOwnedByGroup) Parameters() []*string {
+	return []*string{&a.clientUUID, &a.groupUUID}
+}
+
+func (a *clientOwnedByGroup) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.groupUUID}}
+}
+
+func (a *clientOwnedByGroup) Touches() []string {
+	if a.client == nil {
+		return nil
+	}
+	return []string{a.clientUUID, a.groupUUID, *a.client.GetOwner().GetUuid()}
+}
+
+func (a *clientOwnedByGroup) Init(ctx context.Context, env *action.Environment) error {
+	client, err := action.First[models.ClientClientApplicationable](env.Account1.Client.Client().Get(ctx, &keyhubclient.ClientRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubclient.ClientRequestBuilderGetQueryParameters{
+			Uuid: []string{a.clientUUID},
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("unable to read client with uuid %s: %s", a.clientUUID, action.KeyHubError(err))
+	}
+	a.client = client
+
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+	return nil
+}
+
+func (a *clientOwnedByGroup) IsSatisfied() bool {
+	return *a.client.GetOwner().GetUuid() == a.groupUUID
+}
+
+func (a *clientOwnedByGroup) Requires3() bool {
+	return false
+}
+
+func (a *clientOwnedByGroup) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *clientOwnedByGroup) Execute(ctx context.Context, env *action.Environment) error {
+	transferReq := models.NewRequestTransferApplicationOwnershipRequest()
+	transferReq.SetApplication(a.client)
+	transferReq.SetGroup(a.group)
+	transferReq.SetComment(action.Ptr("automation clientOwnedByGroup"))
+	err := submitAndAccept(ctx, transferReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to transfer application ownership in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *clientOwnedByGroup) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	model.SetName(*a.client.GetUuid(), *a.client.GetName())
+	model.SetName(*a.client.GetOwner().GetUuid(), *a.client.GetOwner().GetName())
+	model.SetClientOwner(*a.client.GetUuid(), *a.client.GetOwner().GetUuid())
+}
+
+func (a *clientOwnedByGroup) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, model.ClientOwner(a.clientUUID), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, a.groupUUID, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetClientOwner(a.clientUUID, a.groupUUID)
+	return nil
+}
+
+func (a *clientOwnedByGroup) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.client.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+		NewAccountInGroup(*env.Account2.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*clientOwnedByGroup) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *clientOwnedByGroup) Revert() action.AutomationAction {
+	return NewClientOwnedByGroup(a.clientUUID, *a.client.GetOwner().GetUuid())
+}
+
+func (a *clientOwnedByGroup) Progress() string {
+	clientName := a.clientUUID
+	if a.client != nil {
+		clientName = *a.client.GetName()
+	}
+	return fmt.Sprintf("Transfering %s", clientName)
+}
+
+func (a *clientOwnedByGroup) String() string {
+	clientName := a.clientUUID
+	if a.client != nil {
+		clientName = *a.client.GetName()
+	}
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Transfer ownership of application '%s' to '%s'", clientName, groupName)
+}
@@ -0,0 +1,255 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubclient "github.com/topicuskeyhub/sdk-go/client"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// clientPermissionGranted ensures the client application holds the permission on either a group
+// or a provisioned system.
+type clientPermissionGranted struct {
+	clientUUID     string
+	permissionType models.ClientOAuth2ClientPermissionType
+	groupUUID      *string
+	systemUUID     *string
+	client         models.ClientClientApplicationable
+	group          models.GroupGroupable
+	system         models.ProvisioningProvisionedSystemable
+	permission     models.ClientOAuth2ClientPermissionable
+}
+
+func NewClientPermissionGranted(clientUUID string, permissionType models.ClientOAuth2ClientPermissionType, groupUUID *string, systemUUID *string) action.AutomationAction {
+	return &clientPermissionGranted{
+		clientUUID:     clientUUID,
+		permissionType: permissionType,
+		groupUUID:      groupUUID,
+		systemUUID:     systemUUID,
+	}
+}
+
+func init() {
+	action.Register("clientPermissionGranted", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 4, 2, 3)
+		if err != nil {
+			return nil, err
+		}
+		err = checkPermissionTarget(parameters[2], parameters[3])
+		if err != nil {
+			return nil, err
+		}
+		permissionType, err := parseClientPermissionType(*parameters[1])
+		if err != nil {
+			return nil, err
+		}
+		return NewClientPermissionGranted(*parameters[0], permissionType, parameters[2], parameters[3]), nil
+	})
+}
+
+func (a *clientPermissionGranted) TypeID() string {
+	return "clientPermissionGranted"
+}
+
+func (a *clientPermissionGranted) Parameters() []*string {
+	return []*string{&a.clientUUID, action.Ptr(a.permissionType.String()), a.groupUUID, a.systemUUID}
+}
+
+func (a *clientPermissionGranted) References() []action.Reference {
+	if a.groupUUID != nil {
+		return []action.Reference{{Kind: groupKind, UUID: *a.groupUUID}}
+	}
+	return []action.Reference{{Kind: systemKind, UUID: *a.systemUUID}}
+}
+
+func (a *clientPermissionGranted) Touches() []string {
+	if a.client == nil {
+		return nil
+	}
+	return []string{a.clientUUID, *a.client.GetOwner().GetUuid(), permissionTarget(a.groupUUID, a.systemUUID), a.approver()}
+}
+
+func (a *clientPermissionGranted) Init(ctx context.Context, env *action.Environment) error {
+	client, err := action.First[models.ClientClientApplicationable](env.Account1.Client.Client().Get(ctx, &keyhubclient.ClientRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubclient.ClientRequestBuilderGetQueryParameters{
+			Uuid: []string{a.clientUUID},
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("unable to read client with uuid %s: %s", a.clientUUID, action.KeyHubError(err))
+	}
+	a.client = client
+
+	if a.groupUUID != nil {
+		group, err := readGroup(ctx, env, *a.groupUUID)
+		if err != nil {
+			return fmt.Errorf("unable to read group with uuid %s: %s", *a.groupUUID, action.KeyHubError(err))
+		}
+		a.group = group
+		a.groupUUID = group.GetUuid()
+	}
+	if a.systemUUID != nil {
+		system, err := readSystem(ctx, env, *a.systemUUID)
+		if err != nil {
+			return fmt.Errorf("unable to read system with uuid %s: %s", *a.systemUUID, action.KeyHubError(err))
+		}
+		a.system = system
+	}
+
+	permission, err := findClientPermission(ctx, env, client, a.permissionType, a.groupUUID, a.systemUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read permissions of client with uuid %s: %s", a.clientUUID, err)
+	}
+	a.permission = permission
+	return nil
+}
+
+// findClientPermission returns the permission of the given type the client holds on the group or
+// system, or nil when the client does not hold it.
+func findClientPermission(ctx context.Context, env *action.Environment, client models.ClientClientApplicationable,
+	permissionType models.ClientOAuth2ClientPermissionType, groupUUID *string, systemUUID *string) (models.ClientOAuth2ClientPermissionable, error) {
+	clientID, err := action.SelfID(client)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := env.Account1.Client.Client().ByClientidInt64(clientID).Permission().Get(ctx, nil)
+	if err != nil {
+		return nil, action.KeyHubError(err)
+	}
+	for _, p := range permissions.GetItems() {
+		if *p.GetValue() != permissionType {
+			continue
+		}
+		if groupUUID != nil && p.GetForGroup() != nil && *p.GetForGroup().GetUuid() == *groupUUID {
+			return p, nil
+		}
+		if systemUUID != nil && p.GetForSystem() != nil && *p.GetForSystem().GetUuid() == *systemUUID {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// permissionTarget returns the UUID of the group or system the permission applies to.
+func permissionTarget(groupUUID *string, systemUUID *string) string {
+	if groupUUID != nil {
+		return *groupUUID
+	}
+	return *systemUUID
+}
+
+func (a *clientPermissionGranted) IsSatisfied() bool {
+	return a.permission != nil
+}
+
+func (a *clientPermissionGranted) Requires3() bool {
+	return false
+}
+
+func (a *clientPermissionGranted) AllowGlobalOptimization() bool {
+	return true
+}
+
+// approver returns the UUID of the group whose managers approve the permission: the group itself,
+// or the owner of the system.
+func (a *clientPermissionGranted) approver() string {
+	if a.groupUUID != nil {
+		return *a.groupUUID
+	}
+	return *a.system.GetOwner().GetUuid()
+}
+
+func (a *clientPermissionGranted) Execute(ctx context.Context, env *action.Environment) error {
+	grantReq := models.NewRequestGrantClientPermissionRequest()
+	grantReq.SetApplication(a.client)
+	grantReq.SetPermissionType(&a.permissionType)
+	if a.group != nil {
+		grantReq.SetGroup(a.group)
+	}
+	if a.system != nil {
+		grantReq.SetSystem(a.system)
+	}
+	grantReq.SetComment(action.Ptr("automation clientPermissionGranted"))
+	err := submitAndAccept(ctx, grantReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to grant client permission in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *clientPermissionGranted) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	model.SetName(*a.client.GetUuid(), *a.client.GetName())
+	model.SetName(*a.client.GetOwner().GetUuid(), *a.client.GetOwner().GetName())
+	model.SetClientOwner(*a.client.GetUuid(), *a.client.GetOwner().GetUuid())
+	if a.system != nil {
+		model.SetName(*a.system.GetUuid(), *a.system.GetName())
+		model.SetName(*a.system.GetOwner().GetUuid(), *a.system.GetOwner().GetName())
+		model.SetSystemOwner(*a.system.GetUuid(), *a.system.GetOwner().GetUuid())
+	}
+	target := permissionTarget(a.groupUUID, a.systemUUID)
+	model.SetClientPermission(a.clientUUID, a.permissionType, target, a.permission != nil)
+}
+
+func (a *clientPermissionGranted) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, model.ClientOwner(a.clientUUID), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	approver := model.SystemOwner(permissionTarget(a.groupUUID, a.systemUUID))
+	if a.groupUUID != nil {
+		approver = *a.groupUUID
+	}
+	err = requireManager(model, approver, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetClientPermission(a.clientUUID, a.permissionType, permissionTarget(a.groupUUID, a.systemUUID), true)
+	return nil
+}
+
+func (a *clientPermissionGranted) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.client.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+		NewAccountInGroup(*env.Account2.Account.GetUuid(), a.approver(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*clientPermissionGranted) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *clientPermissionGranted) Revert() action.AutomationAction {
+	return NewClientPermissionNotGranted(a.clientUUID, a.permissionType, a.groupUUID, a.systemUUID)
+}
+
+func (a *clientPermissionGranted) Progress() string {
+	clientName := a.clientUUID
+	if a.client != nil {
+		clientName = *a.client.GetName()
+	}
+	return fmt.Sprintf("Granting %s", clientName)
+}
+
+func (a *clientPermissionGranted) String() string {
+	clientName := a.clientUUID
+	if a.client != nil {
+		clientName = *a.client.GetName()
+	}
+	return fmt.Sprintf("Grant %s on '%s' to application '%s'", a.permissionType.String(), a.targetName(), clientName)
+}
+
+func (a *clientPermissionGranted) targetName() string {
+	if a.group != nil {
+		return *a.group.GetName()
+	}
+	if a.system != nil {
+		return *a.system.GetName()
+	}
+	return permissionTarget(a.groupUUID, a.systemUUID)
+}
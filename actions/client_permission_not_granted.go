@@ -0,0 +1,173 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubclient "github.com/topicuskeyhub/sdk-go/client"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// clientPermissionNotGranted ensures the client application does not hold the permission on the
+// group or provisioned system. The permission is removed by a manager of the owner of the client.
+// Unlike a grant, this does not go through a request: KeyHub has no request type to revoke a client
+// permission and lets the managers of the owner delete it directly, as revoking only takes away
+// access and needs no consent from the managers of the group or system.
+type clientPermissionNotGranted struct {
+	clientUUID     string
+	permissionType models.ClientOAuth2ClientPermissionType
+	groupUUID      *string
+	systemUUID     *string
+	client         models.ClientClientApplicationable
+	permission     models.ClientOAuth2ClientPermissionable
+}
+
+func NewClientPermissionNotGranted(clientUUID string, permissionType models.ClientOAuth2ClientPermissionType, groupUUID *string, systemUUID *string) action.AutomationAction {
+	return &clientPermissionNotGranted{
+		clientUUID:     clientUUID,
+		permissionType: permissionType,
+		groupUUID:      groupUUID,
+		systemUUID:     systemUUID,
+	}
+}
+
+func init() {
+	action.Register("clientPermissionNotGranted", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 4, 2, 3)
+		if err != nil {
+			return nil, err
+		}
+		err = checkPermissionTarget(parameters[2], parameters[3])
+		if err != nil {
+			return nil, err
+		}
+		permissionType, err := parseClientPermissionType(*parameters[1])
+		if err != nil {
+			return nil, err
+		}
+		return NewClientPermissionNotGranted(*parameters[0], permissionType, parameters[2], parameters[3]), nil
+	})
+}
+
+func (a *clientPermissionNotGranted) TypeID() string {
+	return "clientPermissionNotGranted"
+}
+
+func (a *clientPermissionNotGranted) Parameters() []*string {
+	return []*string{&a.clientUUID, action.Ptr(a.permissionType.String()), a.groupUUID, a.systemUUID}
+}
+
+func (a *clientPermissionNotGranted) Touches() []string {
+	if a.client == nil {
+		return nil
+	}
+	return []string{a.clientUUID, *a.client.GetOwner().GetUuid(), permissionTarget(a.groupUUID, a.systemUUID)}
+}
+
+func (a *clientPermissionNotGranted) Init(ctx context.Context, env *action.Environment) error {
+	client, err := action.First[models.ClientClientApplicationable](env.Account1.Client.Client().Get(ctx, &keyhubclient.ClientRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubclient.ClientRequestBuilderGetQueryParameters{
+			Uuid: []string{a.clientUUID},
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("unable to read client with uuid %s: %s", a.clientUUID, action.KeyHubError(err))
+	}
+	a.client = client
+
+	permission, err := findClientPermission(ctx, env, client, a.permissionType, a.groupUUID, a.systemUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read permissions of client with uuid %s: %s", a.clientUUID, err)
+	}
+	a.permission = permission
+	return nil
+}
+
+func (a *clientPermissionNotGranted) IsSatisfied() bool {
+	return a.permission == nil
+}
+
+func (a *clientPermissionNotGranted) Requires3() bool {
+	return false
+}
+
+func (a *clientPermissionNotGranted) AllowGlobalOptimization() bool {
+	return true
+}
+
+func (a *clientPermissionNotGranted) Execute(ctx context.Context, env *action.Environment) error {
+	clientID, err := action.SelfID(a.client)
+	if err != nil {
+		return fmt.Errorf("invalid client in '%s': %s", a.String(), err)
+	}
+	permissionID, err := action.SelfID(a.permission)
+	if err != nil {
+		return fmt.Errorf("invalid permission in '%s': %s", a.String(), err)
+	}
+	err = env.Account1.Client.Client().ByClientidInt64(clientID).Permission().ByPermissionidInt64(permissionID).Delete(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot remove client permission in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *clientPermissionNotGranted) Observe(model *action.Model) {
+	model.SetName(*a.client.GetUuid(), *a.client.GetName())
+	model.SetName(*a.client.GetOwner().GetUuid(), *a.client.GetOwner().GetName())
+	model.SetClientOwner(*a.client.GetUuid(), *a.client.GetOwner().GetUuid())
+	target := permissionTarget(a.groupUUID, a.systemUUID)
+	model.SetClientPermission(a.clientUUID, a.permissionType, target, a.permission != nil)
+}
+
+func (a *clientPermissionNotGranted) Simulate(env *action.Environment, model *action.Model) error {
+	target := permissionTarget(a.groupUUID, a.systemUUID)
+	if !model.HasClientPermission(a.clientUUID, a.permissionType, target) {
+		return fmt.Errorf("%s does not hold %s on %s", model.Name(a.clientUUID), a.permissionType.String(), model.Name(target))
+	}
+	err := requireManager(model, model.ClientOwner(a.clientUUID), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetClientPermission(a.clientUUID, a.permissionType, target, false)
+	return nil
+}
+
+func (a *clientPermissionNotGranted) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.client.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*clientPermissionNotGranted) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *clientPermissionNotGranted) Revert() action.AutomationAction {
+	return NewClientPermissionGranted(a.clientUUID, a.permissionType, a.groupUUID, a.systemUUID)
+}
+
+func (a *clientPermissionNotGranted) Progress() string {
+	clientName := a.clientUUID
+	if a.client != nil {
+		clientName = *a.client.GetName()
+	}
+	return fmt.Sprintf("Revoking %s", clientName)
+}
+
+func (a *clientPermissionNotGranted) String() string {
+	clientName := a.clientUUID
+	if a.client != nil {
+		clientName = *a.client.GetName()
+	}
+	targetName := permissionTarget(a.groupUUID, a.systemUUID)
+	if a.permission != nil && a.permission.GetForGroup() != nil {
+		targetName = *a.permission.GetForGroup().GetName()
+	} else if a.permission != nil && a.permission.GetForSystem() != nil {
+		targetName = *a.permission.GetForSystem().GetName()
+	}
+	return fmt.Sprintf("Revoke %s on '%s' from application '%s'", a.permissionType.String(), targetName, clientName)
+}
@@ -10,7 +10,6 @@ import (
 	"unicode/utf8"
 
 	"github.com/topicuskeyhub/automation-framework/action"
-	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
 	"github.com/topicuskeyhub/sdk-go/models"
 )
 
@@ -31,6 +30,20 @@ func NewConnectGroupAuthorization(subjectGroupUUID string, authorizingGroupUUID
 	}
 }
 
+func init() {
+	action.Register("connectGroupAuthorization", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 3)
+		if err != nil {
+			return nil, err
+		}
+		authType, err := parseAuthorizationType(*parameters[2])
+		if err != nil {
+			return nil, err
+		}
+		return NewConnectGroupAuthorization(*parameters[0], *parameters[1], authType), nil
+	})
+}
+
 func (a *connectGroupAuthorization) TypeID() string {
 	return "connectGroupAuthorization"
 }
@@ -39,26 +52,36 @@ func (a *connectGroupAuthorization) Parameters() []*string {
 	return []*string{&a.subjectGroupUUID, &a.authorizingGroupUUID, action.Ptr(a.authorizationType.String())}
 }
 
-func (a *connectGroupAuthorization) Init(ctx context.Context, env *action.Environment) {
-	subjectGroup, err := action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
-			Uuid: []string{a.subjectGroupUUID},
-		},
-	}))
+func (a *connectGroupAuthorization) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.subjectGroupUUID}, {Kind: groupKind, UUID: a.authorizingGroupUUID}}
+}
+
+func (a *connectGroupAuthorization) Touches() []string {
+	if a.subjectGroup == nil {
+		return nil
+	}
+	ret := []string{a.subjectGroupUUID, a.authorizingGroupUUID}
+	if groupSet := findCurrentAuthorizingGroup(a.subjectGroup, a.authorizationType); groupSet != nil {
+		ret = append(ret, *groupSet.GetUuid())
+	}
+	return ret
+}
+
+func (a *connectGroupAuthorization) Init(ctx context.Context, env *action.Environment) error {
+	subjectGroup, err := readGroup(ctx, env, a.subjectGroupUUID)
 	if err != nil {
-		action.Abort(a, "unable to read group with uuid %s: %s", a.subjectGroupUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.subjectGroupUUID, action.KeyHubError(err))
 	}
 	a.subjectGroup = subjectGroup
+	a.subjectGroupUUID = *subjectGroup.GetUuid()
 
-	authorizingGroup, err := action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
-			Uuid: []string{a.authorizingGroupUUID},
-		},
-	}))
+	authorizingGroup, err := readGroup(ctx, env, a.authorizingGroupUUID)
 	if err != nil {
-		action.Abort(a, "unable to read group with uuid %s: %s", a.authorizingGroupUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.authorizingGroupUUID, action.KeyHubError(err))
 	}
 	a.authorizingGroup = authorizingGroup
+	a.authorizingGroupUUID = *authorizingGroup.GetUuid()
+	return nil
 }
 
 func firstCharToUpper(input string) string {
@@ -68,7 +91,7 @@ func firstCharToUpper(input string) string {
 
 func (a *connectGroupAuthorization) IsSatisfied() bool {
 	groupSet := findCurrentAuthorizingGroup(a.subjectGroup, a.authorizationType)
-	return groupSet != nil && action.Self(groupSet).GetId() == action.Self(a.authorizingGroup).GetId()
+	return groupSet != nil && *groupSet.GetUuid() == a.authorizingGroupUUID
 }
 
 func (a *connectGroupAuthorization) Requires3() bool {
@@ -107,6 +130,31 @@ func (a *connectGroupAuthorization) Execute(ctx context.Context, env *action.Env
 	return nil
 }
 
+func (a *connectGroupAuthorization) Observe(model *action.Model) {
+	model.ObserveGroup(a.subjectGroup)
+	model.ObserveGroup(a.authorizingGroup)
+}
+
+func (a *connectGroupAuthorization) Simulate(env *action.Environment, model *action.Model) error {
+	current := model.AuthorizingGroup(a.subjectGroupUUID, a.authorizationType)
+	if current != "" {
+		err := requireManager(model, current, uuidOf(env.Account2))
+		if err != nil {
+			return err
+		}
+	}
+	err := requireManager(model, a.authorizingGroupUUID, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, a.subjectGroupUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetAuthorizingGroup(a.subjectGroupUUID, a.authorizationType, a.authorizingGroupUUID)
+	return nil
+}
+
 func (a *connectGroupAuthorization) Setup(env *action.Environment) []action.AutomationAction {
 	ret := make([]action.AutomationAction, 0)
 	groupSet := findCurrentAuthorizingGroup(a.subjectGroup, a.authorizationType)
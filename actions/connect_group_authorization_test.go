@@ -0,0 +1,50 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func TestConnectGroupAuthorization(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "connect",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				team := f.AddGroup("Team")
+				auditors := f.AddGroup("Auditors")
+				return actions.NewConnectGroupAuthorization(team.UUID, auditors.UUID, models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE), func(t *testing.T) {
+					if f.AuthorizingGroup(team, models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE) != auditors {
+						t.Errorf("Team is not audited by Auditors")
+					}
+				}
+			},
+			plan: []string{
+				"Add admin2 to 'Auditors' as manager",
+				"Add admin1 to 'Team' as manager",
+				"Setup auditing authorization on 'Team' by 'Auditors'",
+				"Remove admin1 from 'Team'",
+				"Remove admin2 from 'Auditors'",
+			},
+		},
+		{
+			name: "already connected",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				team := f.AddGroup("Team")
+				auditors := f.AddGroup("Auditors")
+				f.SetAuthorizingGroup(team, models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE, auditors)
+				return actions.NewConnectGroupAuthorization(team.UUID, auditors.UUID, models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE), func(t *testing.T) {
+					if f.AuthorizingGroup(team, models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE) != auditors {
+						t.Errorf("Team is not audited by Auditors")
+					}
+				}
+			},
+			plan: []string{},
+		},
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 
 	"github.com/topicuskeyhub/automation-framework/action"
-	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
 	"github.com/topicuskeyhub/sdk-go/models"
 )
 
@@ -25,6 +24,20 @@ func NewDisconnectGroupAuthorization(subjectGroupUUID string, authorizationType
 	}
 }
 
+func init() {
+	action.Register("disconnectGroupAuthorization", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		authType, err := parseAuthorizationType(*parameters[1])
+		if err != nil {
+			return nil, err
+		}
+		return NewDisconnectGroupAuthorization(*parameters[0], authType), nil
+	})
+}
+
 func (a *disconnectGroupAuthorization) TypeID() string {
 	return "disconnectGroupAuthorization"
 }
@@ -33,16 +46,29 @@ func (a *disconnectGroupAuthorization) Parameters() []*string {
 	return []*string{&a.subjectGroupUUID, action.Ptr(a.authorizationType.String())}
 }
 
-func (a *disconnectGroupAuthorization) Init(ctx context.Context, env *action.Environment) {
-	subjectGroup, err := action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
-			Uuid: []string{a.subjectGroupUUID},
-		},
-	}))
+func (a *disconnectGroupAuthorization) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.subjectGroupUUID}}
+}
+
+func (a *disconnectGroupAuthorization) Touches() []string {
+	if a.subjectGroup == nil {
+		return nil
+	}
+	ret := []string{a.subjectGroupUUID}
+	if groupSet := findCurrentAuthorizingGroup(a.subjectGroup, a.authorizationType); groupSet != nil {
+		ret = append(ret, *groupSet.GetUuid())
+	}
+	return ret
+}
+
+func (a *disconnectGroupAuthorization) Init(ctx context.Context, env *action.Environment) error {
+	subjectGroup, err := readGroup(ctx, env, a.subjectGroupUUID)
 	if err != nil {
-		action.Abort(a, "unable to read group with uuid %s: %s", a.subjectGroupUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.subjectGroupUUID, action.KeyHubError(err))
 	}
 	a.subjectGroup = subjectGroup
+	a.subjectGroupUUID = *subjectGroup.GetUuid()
+	return nil
 }
 
 func (a *disconnectGroupAuthorization) IsSatisfied() bool {
@@ -72,6 +98,27 @@ func (a *disconnectGroupAuthorization) Execute(ctx context.Context, env *action.
 	return nil
 }
 
+func (a *disconnectGroupAuthorization) Observe(model *action.Model) {
+	model.ObserveGroup(a.subjectGroup)
+}
+
+func (a *disconnectGroupAuthorization) Simulate(env *action.Environment, model *action.Model) error {
+	current := model.AuthorizingGroup(a.subjectGroupUUID, a.authorizationType)
+	if current == "" {
+		return fmt.Errorf("%s has no %s authorization", model.Name(a.subjectGroupUUID), describe(a.authorizationType))
+	}
+	err := requireManager(model, current, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, a.subjectGroupUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetAuthorizingGroup(a.subjectGroupUUID, a.authorizationType, "")
+	return nil
+}
+
 func (a *disconnectGroupAuthorization) Setup(env *action.Environment) []action.AutomationAction {
 	groupSet := findCurrentAuthorizingGroup(a.subjectGroup, a.authorizationType)
 	return []action.AutomationAction{
@@ -0,0 +1,159 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubgroupclassification "github.com/topicuskeyhub/sdk-go/groupclassification"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+type groupClassification struct {
+	groupUUID          string
+	classificationUUID string
+	group              models.GroupGroupable
+	classification     models.GroupGroupClassificationable
+}
+
+func NewGroupClassification(groupUUID string, classificationUUID string) action.AutomationAction {
+	return &groupClassification{
+		groupUUID:          groupUUID,
+		classificationUUID: classificationUUID,
+	}
+}
+
+func init() {
+	action.Register("groupClassification", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupClassification(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *groupClassification) TypeID() string {
+	return "groupClassification"
+}
+
+func (a *groupClassification) Parameters() []*string {
+	return []*string{&a.groupUUID, &a.classificationUUID}
+}
+
+func (a *groupClassification) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.groupUUID}}
+}
+
+func (a *groupClassification) Touches() []string {
+	return []string{a.groupUUID, a.classificationUUID}
+}
+
+func (a *groupClassification) Init(ctx context.Context, env *action.Environment) error {
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+
+	classification, err := action.First[models.GroupGroupClassificationable](env.Account1.Client.Groupclassification().Get(ctx, &keyhubgroupclassification.GroupclassificationRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubgroupclassification.GroupclassificationRequestBuilderGetQueryParameters{
+			Uuid: []string{a.classificationUUID},
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("unable to read group classification with uuid %s: %s", a.classificationUUID, action.KeyHubError(err))
+	}
+	a.classification = classification
+	return nil
+}
+
+func (a *groupClassification) IsSatisfied() bool {
+	current := a.group.GetClassification()
+	return current != nil && *current.GetUuid() == a.classificationUUID
+}
+
+func (a *groupClassification) Requires3() bool {
+	return false
+}
+
+func (a *groupClassification) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *groupClassification) Execute(ctx context.Context, env *action.Environment) error {
+	changeReq := models.NewRequestChangeGroupClassificationRequest()
+	changeReq.SetGroup(a.group)
+	changeReq.SetGroupClassification(a.classification)
+	changeReq.SetComment(action.Ptr("automation groupClassification"))
+	err := submitAndAccept(ctx, changeReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to change classification in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupClassification) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	if a.classification != nil {
+		model.SetName(*a.classification.GetUuid(), *a.classification.GetName())
+	}
+}
+
+func (a *groupClassification) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, a.groupUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetClassification(a.groupUUID, a.classificationUUID)
+	return nil
+}
+
+// Setup makes account 1, which requests the change, a manager of the group. The change is approved
+// by account 2 as a KeyHub administrator.
+func (a *groupClassification) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (a *groupClassification) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+// Revert restores the current classification. A classification cannot be removed from a group, so
+// a group without classification is left as is, which String mentions.
+func (a *groupClassification) Revert() action.AutomationAction {
+	current := a.group.GetClassification()
+	if current == nil {
+		return nil
+	}
+	return NewGroupClassification(a.groupUUID, *current.GetUuid())
+}
+
+func (a *groupClassification) Progress() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Classifying %s", groupName)
+}
+
+func (a *groupClassification) String() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	classificationName := a.classificationUUID
+	if a.classification != nil {
+		classificationName = *a.classification.GetName()
+	}
+	if a.group != nil && a.group.GetClassification() == nil {
+		return fmt.Sprintf("Change classification of '%s' to '%s' (cannot be reverted, the group has no classification)", groupName, classificationName)
+	}
+	return fmt.Sprintf("Change classification of '%s' to '%s'", groupName, classificationName)
+}
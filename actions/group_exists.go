@@ -0,0 +1,207 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
+	keyhubgroupclassification "github.com/topicuskeyhub/sdk-go/groupclassification"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupExists ensures a group with the given name exists. Groups are identified by name, because
+// KeyHub assigns the UUID when the group is created. Later actions can refer to the group with
+// GroupByName.
+type groupExists struct {
+	name               string
+	managerUUID        string
+	classificationUUID *string
+	orgUnitUUID        *string
+	group              models.GroupGroupable
+	manager            models.AuthAccountable
+	classification     models.GroupGroupClassificationable
+	orgUnit            models.OrganizationOrganizationalUnitable
+}
+
+func NewGroupExists(name string, managerUUID string, classificationUUID *string, orgUnitUUID *string) action.AutomationAction {
+	return &groupExists{
+		name:               name,
+		managerUUID:        managerUUID,
+		classificationUUID: classificationUUID,
+		orgUnitUUID:        orgUnitUUID,
+	}
+}
+
+func init() {
+	action.Register("groupExists", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 4, 2, 3)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupExists(*parameters[0], *parameters[1], parameters[2], parameters[3]), nil
+	})
+}
+
+func (a *groupExists) TypeID() string {
+	return "groupExists"
+}
+
+func (a *groupExists) Parameters() []*string {
+	return []*string{&a.name, &a.managerUUID, a.classificationUUID, a.orgUnitUUID}
+}
+
+func (a *groupExists) References() []action.Reference {
+	ret := []action.Reference{{Kind: groupKind, UUID: GroupByName(a.name)}, {Kind: accountKind, UUID: a.managerUUID}}
+	if a.orgUnitUUID != nil {
+		ret = append(ret, action.Reference{Kind: orgUnitKind, UUID: *a.orgUnitUUID})
+	}
+	return ret
+}
+
+func (a *groupExists) Touches() []string {
+	ret := []string{GroupByName(a.name), a.managerUUID}
+	if a.group != nil {
+		ret = append(ret, *a.group.GetUuid())
+	}
+	if a.orgUnitUUID != nil {
+		ret = append(ret, *a.orgUnitUUID)
+	}
+	return ret
+}
+
+// findGroupByName returns the group with the given name, or nil when no such group exists.
+func findGroupByName(ctx context.Context, env *action.Environment, name string, additional ...string) (models.GroupGroupable, error) {
+	groups, err := env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
+			Name:       []string{name},
+			Additional: additional,
+		},
+	})
+	if err != nil {
+		return nil, action.KeyHubError(err)
+	}
+	if len(groups.GetItems()) == 0 {
+		return nil, nil
+	}
+	return groups.GetItems()[0], nil
+}
+
+func (a *groupExists) Init(ctx context.Context, env *action.Environment) error {
+	group, err := findGroupByName(ctx, env, a.name)
+	if err != nil {
+		return fmt.Errorf("unable to read group with name %s: %s", a.name, err)
+	}
+	a.group = group
+
+	manager, err := readAccount(ctx, env, a.managerUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read account with uuid %s: %s", a.managerUUID, action.KeyHubError(err))
+	}
+	a.manager = manager
+
+	if a.classificationUUID != nil {
+		classification, err := action.First[models.GroupGroupClassificationable](env.Account1.Client.Groupclassification().Get(ctx, &keyhubgroupclassification.GroupclassificationRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubgroupclassification.GroupclassificationRequestBuilderGetQueryParameters{
+				Uuid: []string{*a.classificationUUID},
+			},
+		}))
+		if err != nil {
+			return fmt.Errorf("unable to read group classification with uuid %s: %s", *a.classificationUUID, action.KeyHubError(err))
+		}
+		a.classification = classification
+	}
+	if a.orgUnitUUID != nil {
+		orgUnit, err := readOrganizationalUnit(ctx, env, *a.orgUnitUUID)
+		if err != nil {
+			return fmt.Errorf("unable to read organisational unit with uuid %s: %s", *a.orgUnitUUID, action.KeyHubError(err))
+		}
+		a.orgUnit = orgUnit
+	}
+	return nil
+}
+
+func (a *groupExists) IsSatisfied() bool {
+	return a.group != nil
+}
+
+func (a *groupExists) Requires3() bool {
+	return false
+}
+
+func (a *groupExists) AllowGlobalOptimization() bool {
+	return true
+}
+
+func (a *groupExists) Execute(ctx context.Context, env *action.Environment) error {
+	managerSelf, err := action.Self(a.manager)
+	if err != nil {
+		return fmt.Errorf("invalid manager in '%s': %s", a.String(), err)
+	}
+	admin := models.NewGroupGroupAccount()
+	admin.SetLinks([]models.RestLinkable{managerSelf})
+	admin.SetRights(action.Ptr(models.MANAGER_GROUPGROUPRIGHTS))
+	admins := models.NewGroupGroupAccountLinkableWrapper()
+	admins.SetItems([]models.GroupGroupAccountable{admin})
+	additionalObjects := models.NewGroupGroup_additionalObjects()
+	additionalObjects.SetAdmins(admins)
+
+	newGroup := models.NewGroupGroup()
+	newGroup.SetName(&a.name)
+	newGroup.SetAdditionalObjects(additionalObjects)
+	if a.classification != nil {
+		newGroup.SetClassification(a.classification)
+	}
+	if a.orgUnit != nil {
+		newGroup.SetOrganizationalUnit(a.orgUnit)
+	}
+	wrapper := models.NewGroupGroupLinkableWrapper()
+	wrapper.SetItems([]models.GroupGroupable{newGroup})
+	_, err = action.First[models.GroupGroupable](env.Account1.Client.Group().Post(ctx, wrapper, nil))
+	if err != nil {
+		return fmt.Errorf("cannot create group in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupExists) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	model.ObserveAccount(a.manager)
+}
+
+func (a *groupExists) Simulate(env *action.Environment, model *action.Model) error {
+	if model.GroupExists(a.name) {
+		return fmt.Errorf("group %s already exists", a.name)
+	}
+	model.SetGroupExists(a.name, true)
+	model.SetName(GroupByName(a.name), a.name)
+	model.SetRights(GroupByName(a.name), a.managerUUID, models.MANAGER_GROUPGROUPRIGHTS)
+	return nil
+}
+
+func (a *groupExists) Setup(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupExists) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupExists) Revert() action.AutomationAction {
+	return NewGroupNotExists(a.name)
+}
+
+func (a *groupExists) Progress() string {
+	return fmt.Sprintf("Creating %s", a.name)
+}
+
+func (a *groupExists) String() string {
+	managerName := a.managerUUID
+	if a.manager != nil {
+		managerName = *a.manager.GetUsername()
+	}
+	return fmt.Sprintf("Create group '%s' managed by %s", a.name, managerName)
+}
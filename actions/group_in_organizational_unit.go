@@ -0,0 +1,154 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupInOrganizationalUnit ensures the group is in the organizational unit. KeyHub has no archived
+// state for groups, so a group is archived by moving it to the organizational unit that holds the
+// archived groups.
+type groupInOrganizationalUnit struct {
+	groupUUID   string
+	orgUnitUUID string
+	group       models.GroupGroupable
+	orgUnit     models.OrganizationOrganizationalUnitable
+}
+
+func NewGroupInOrganizationalUnit(groupUUID string, orgUnitUUID string) action.AutomationAction {
+	return &groupInOrganizationalUnit{
+		groupUUID:   groupUUID,
+		orgUnitUUID: orgUnitUUID,
+	}
+}
+
+func init() {
+	action.Register("groupInOrganizationalUnit", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupInOrganizationalUnit(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *groupInOrganizationalUnit) TypeID() string {
+	return "groupInOrganizationalUnit"
+}
+
+func (a *groupInOrganizationalUnit) Parameters() []*string {
+	return []*string{&a.groupUUID, &a.orgUnitUUID}
+}
+
+func (a *groupInOrganizationalUnit) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.groupUUID}, {Kind: orgUnitKind, UUID: a.orgUnitUUID}}
+}
+
+func (a *groupInOrganizationalUnit) Touches() []string {
+	ret := []string{a.groupUUID, a.orgUnitUUID}
+	if a.group != nil && a.group.GetOrganizationalUnit() != nil {
+		ret = append(ret, *a.group.GetOrganizationalUnit().GetUuid())
+	}
+	return ret
+}
+
+func (a *groupInOrganizationalUnit) Init(ctx context.Context, env *action.Environment) error {
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+
+	orgUnit, err := readOrganizationalUnit(ctx, env, a.orgUnitUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read organizational unit with uuid %s: %s", a.orgUnitUUID, action.KeyHubError(err))
+	}
+	a.orgUnit = orgUnit
+	return nil
+}
+
+func (a *groupInOrganizationalUnit) IsSatisfied() bool {
+	current := a.group.GetOrganizationalUnit()
+	return current != nil && *current.GetUuid() == a.orgUnitUUID
+}
+
+func (a *groupInOrganizationalUnit) Requires3() bool {
+	return false
+}
+
+func (a *groupInOrganizationalUnit) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *groupInOrganizationalUnit) Execute(ctx context.Context, env *action.Environment) error {
+	err := updateGroup(ctx, env, a.group, func(group models.GroupGroupable) {
+		group.SetOrganizationalUnit(a.orgUnit)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot move group in '%s': %s", a.String(), err)
+	}
+	return nil
+}
+
+func (a *groupInOrganizationalUnit) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	if a.orgUnit != nil {
+		model.SetName(*a.orgUnit.GetUuid(), *a.orgUnit.GetName())
+	}
+}
+
+func (a *groupInOrganizationalUnit) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, a.groupUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetGroupOrganizationalUnit(a.groupUUID, a.orgUnitUUID)
+	return nil
+}
+
+func (a *groupInOrganizationalUnit) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (a *groupInOrganizationalUnit) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+// Revert moves the group back to its current organizational unit. Every group in KeyHub is in an
+// organizational unit, so there is nothing to revert to for a group without one.
+func (a *groupInOrganizationalUnit) Revert() action.AutomationAction {
+	current := a.group.GetOrganizationalUnit()
+	if current == nil {
+		return nil
+	}
+	return NewGroupInOrganizationalUnit(a.groupUUID, *current.GetUuid())
+}
+
+func (a *groupInOrganizationalUnit) Progress() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Moving %s", groupName)
+}
+
+func (a *groupInOrganizationalUnit) String() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	orgUnitName := a.orgUnitUUID
+	if a.orgUnit != nil {
+		orgUnitName = *a.orgUnit.GetName()
+	}
+	return fmt.Sprintf("Move group '%s' to organizational unit '%s'", groupName, orgUnitName)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupNotExists ensures no group with the given name exists, by requesting its removal.
+type groupNotExists struct {
+	name        string
+	group       models.GroupGroupable
+	managerUUID string
+}
+
+func NewGroupNotExists(name string) action.AutomationAction {
+	return &groupNotExists{
+		name: name,
+	}
+}
+
+func init() {
+	action.Register("groupNotExists", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 1)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupNotExists(*parameters[0]), nil
+	})
+}
+
+func (a *groupNotExists) TypeID() string {
+	return "groupNotExists"
+}
+
+func (a *groupNotExists) Parameters() []*string {
+	return []*string{&a.name}
+}
+
+func (a *groupNotExists) Touches() []string {
+	ret := []string{GroupByName(a.name)}
+	if a.group != nil {
+		ret = append(ret, *a.group.GetUuid())
+	}
+	return ret
+}
+
+func (a *groupNotExists) Init(ctx context.Context, env *action.Environment) error {
+	group, err := findGroupByName(ctx, env, a.name, "accounts")
+	if err != nil {
+		return fmt.Errorf("unable to read group with name %s: %s", a.name, err)
+	}
+	a.group = group
+	a.managerUUID = ""
+	if group != nil {
+		// The group is recreated on revert with its first manager. Account 1 is made a manager
+		// in Setup, so it is used when the group has no other manager.
+		a.managerUUID = *env.Account1.Account.GetUuid()
+		for _, m := range group.GetAdditionalObjects().GetAccounts().GetItems() {
+			if *m.GetRights() == models.MANAGER_GROUPGROUPRIGHTS && *m.GetUuid() != *env.Account2.Account.GetUuid() {
+				a.managerUUID = *m.GetUuid()
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (a *groupNotExists) IsSatisfied() bool {
+	return a.group == nil
+}
+
+func (a *groupNotExists) Requires3() bool {
+	return false
+}
+
+func (a *groupNotExists) AllowGlobalOptimization() bool {
+	return true
+}
+
+func (a *groupNotExists) Execute(ctx context.Context, env *action.Environment) error {
+	removeReq := models.NewRequestRemoveGroupRequest()
+	removeReq.SetGroup(a.group)
+	removeReq.SetGroupName(&a.name)
+	removeReq.SetComment(action.Ptr("automation groupNotExists"))
+	err := submitAndAccept(ctx, removeReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to remove group in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupNotExists) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+}
+
+func (a *groupNotExists) Simulate(env *action.Environment, model *action.Model) error {
+	if !model.GroupExists(a.name) {
+		return fmt.Errorf("group %s does not exist", a.name)
+	}
+	model.SetGroupExists(a.name, false)
+	return nil
+}
+
+// Setup makes account 1, which requests the removal, and account 2 managers of the group. These
+// memberships are removed together with the group, so they are not cleaned up.
+func (a *groupNotExists) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		newManagerOfRemovedGroup(*env.Account1.Account.GetUuid(), *a.group.GetUuid()),
+		newManagerOfRemovedGroup(*env.Account2.Account.GetUuid(), *a.group.GetUuid()),
+	}
+}
+
+func (a *groupNotExists) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+// Revert recreates the group with its first manager, classification and organizational unit. Its
+// other members are not restored.
+func (a *groupNotExists) Revert() action.AutomationAction {
+	if a.group == nil {
+		return nil
+	}
+	var classificationUUID, orgUnitUUID *string
+	if a.group.GetClassification() != nil {
+		classificationUUID = a.group.GetClassification().GetUuid()
+	}
+	if a.group.GetOrganizationalUnit() != nil {
+		orgUnitUUID = a.group.GetOrganizationalUnit().GetUuid()
+	}
+	return NewGroupExists(a.name, a.managerUUID, classificationUUID, orgUnitUUID)
+}
+
+func (a *groupNotExists) Progress() string {
+	return fmt.Sprintf("Removing %s", a.name)
+}
+
+func (a *groupNotExists) String() string {
+	return fmt.Sprintf("Remove group '%s'", a.name)
+}
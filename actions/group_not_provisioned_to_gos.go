@@ -0,0 +1,181 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupNotProvisionedToGOS ensures the group is not one of the provisioned groups of the group on
+// system. The link is removed by a manager of the owner of the group on system.
+type groupNotProvisionedToGOS struct {
+	systemUUID      string
+	gosNameInSystem string
+	groupUUID       string
+	system          models.ProvisioningProvisionedSystemable
+	gos             models.ProvisioningGroupOnSystemable
+	provGroup       models.GroupProvisioningGroupable
+}
+
+func NewGroupNotProvisionedToGOS(systemUUID string, gosNameInSystem string, groupUUID string) action.AutomationAction {
+	return &groupNotProvisionedToGOS{
+		systemUUID:      systemUUID,
+		gosNameInSystem: gosNameInSystem,
+		groupUUID:       groupUUID,
+	}
+}
+
+func init() {
+	action.Register("groupNotProvisionedToGOS", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 3)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupNotProvisionedToGOS(*parameters[0], *parameters[1], *parameters[2]), nil
+	})
+}
+
+func (a *groupNotProvisionedToGOS) TypeID() string {
+	return "groupNotProvisionedToGOS"
+}
+
+func (a *groupNotProvisionedToGOS) Parameters() []*string {
+	return []*string{&a.systemUUID, &a.gosNameInSystem, &a.groupUUID}
+}
+
+func (a *groupNotProvisionedToGOS) References() []action.Reference {
+	return []action.Reference{{Kind: systemKind, UUID: a.systemUUID}}
+}
+
+func (a *groupNotProvisionedToGOS) Touches() []string {
+	if a.gos == nil {
+		return nil
+	}
+	return []string{a.systemUUID, a.groupUUID, *a.gos.GetOwner().GetUuid()}
+}
+
+func (a *groupNotProvisionedToGOS) Init(ctx context.Context, env *action.Environment) error {
+	system, gos, err := readGroupOnSystem(ctx, env, a.systemUUID, a.gosNameInSystem)
+	if err != nil {
+		return err
+	}
+	a.system = system
+	a.gos = gos
+
+	provGroup, err := findProvisioningGroup(ctx, env, system, gos, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read provisioned groups of %s: %s", a.gosNameInSystem, err)
+	}
+	a.provGroup = provGroup
+	return nil
+}
+
+func (a *groupNotProvisionedToGOS) IsSatisfied() bool {
+	return a.provGroup == nil
+}
+
+func (a *groupNotProvisionedToGOS) Requires3() bool {
+	return false
+}
+
+func (a *groupNotProvisionedToGOS) AllowGlobalOptimization() bool {
+	return true
+}
+
+func (a *groupNotProvisionedToGOS) Execute(ctx context.Context, env *action.Environment) error {
+	systemID, err := action.SelfID(a.system)
+	if err != nil {
+		return fmt.Errorf("invalid system in '%s': %s", a.String(), err)
+	}
+	if a.provGroup == nil {
+		// The group on system or its provisioned group did not exist yet when the plan was made,
+		// e.g. when reverting the provisioning.
+		a.gos, err = findGroupOnSystem(ctx, env, a.system, a.gosNameInSystem)
+		if err != nil {
+			return fmt.Errorf("unable to read group on system in '%s': %s", a.String(), err)
+		}
+		a.provGroup, err = findProvisioningGroup(ctx, env, a.system, a.gos, a.groupUUID)
+		if err != nil {
+			return fmt.Errorf("unable to read provisioned groups in '%s': %s", a.String(), err)
+		}
+		if a.provGroup == nil {
+			return nil
+		}
+	}
+	gosID, err := action.SelfID(a.gos)
+	if err != nil {
+		return fmt.Errorf("invalid group on system in '%s': %s", a.String(), err)
+	}
+	provGroupID, err := action.SelfID(a.provGroup)
+	if err != nil {
+		return fmt.Errorf("invalid provisioned group in '%s': %s", a.String(), err)
+	}
+	err = env.Account1.Client.System().BySystemidInt64(systemID).Group().ByGroupidInt64(gosID).Provgroup().ByProvgroupidInt64(provGroupID).Delete(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot remove provisioned group in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupNotProvisionedToGOS) Observe(model *action.Model) {
+	observeGroupOnSystem(model, a.systemUUID, a.gosNameInSystem, a.gos)
+	if a.provGroup != nil {
+		model.SetName(a.groupUUID, *a.provGroup.GetGroup().GetName())
+	}
+	model.SetProvisioned(groupOnSystemKey(a.systemUUID, a.gosNameInSystem, a.gos), a.groupUUID, a.provGroup != nil)
+}
+
+func (a *groupNotProvisionedToGOS) Simulate(env *action.Environment, model *action.Model) error {
+	gosKey := groupOnSystemKey(a.systemUUID, a.gosNameInSystem, a.gos)
+	if !model.IsProvisioned(gosKey, a.groupUUID) {
+		return fmt.Errorf("%s is not provisioned to %s", model.Name(a.groupUUID), a.gosNameInSystem)
+	}
+	err := requireManager(model, model.GroupOnSystemOwner(gosKey), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetProvisioned(gosKey, a.groupUUID, false)
+	return nil
+}
+
+func (a *groupNotProvisionedToGOS) Setup(env *action.Environment) []action.AutomationAction {
+	if a.gos == nil {
+		return make([]action.AutomationAction, 0)
+	}
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.gos.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*groupNotProvisionedToGOS) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupNotProvisionedToGOS) Revert() action.AutomationAction {
+	return NewGroupProvisionedToGOS(a.systemUUID, a.gosNameInSystem, a.groupUUID, nil)
+}
+
+func (a *groupNotProvisionedToGOS) Progress() string {
+	groupName := a.groupUUID
+	if a.provGroup != nil {
+		groupName = *a.provGroup.GetGroup().GetName()
+	}
+	return fmt.Sprintf("Unprovisioning %s", groupName)
+}
+
+func (a *groupNotProvisionedToGOS) String() string {
+	systemName := a.systemUUID
+	if a.system != nil {
+		systemName = *a.system.GetName()
+	}
+	groupName := a.groupUUID
+	if a.provGroup != nil {
+		groupName = *a.provGroup.GetGroup().GetName()
+	}
+	return fmt.Sprintf("Stop provisioning '%s' to '%s' on '%s'", groupName, a.gosNameInSystem, systemName)
+}
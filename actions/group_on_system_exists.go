@@ -0,0 +1,215 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+	keyhubsystem "github.com/topicuskeyhub/sdk-go/system"
+)
+
+// groupOnSystemExists ensures a group on system with the given name exists on the provisioned
+// system. The group on system is requested by a manager of its owner and accepted by a manager of
+// the owner of the system.
+type groupOnSystemExists struct {
+	systemUUID   string
+	nameInSystem string
+	gosType      models.ProvisioningGroupOnSystemType
+	displayName  string
+	ownerUUID    string
+	system       models.ProvisioningProvisionedSystemable
+	gos          models.ProvisioningGroupOnSystemable
+	owner        models.GroupGroupable
+}
+
+func NewGroupOnSystemExists(systemUUID string, nameInSystem string, gosType models.ProvisioningGroupOnSystemType, displayName string, ownerUUID string) action.AutomationAction {
+	return &groupOnSystemExists{
+		systemUUID:   systemUUID,
+		nameInSystem: nameInSystem,
+		gosType:      gosType,
+		displayName:  displayName,
+		ownerUUID:    ownerUUID,
+	}
+}
+
+func init() {
+	action.Register("groupOnSystemExists", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 5)
+		if err != nil {
+			return nil, err
+		}
+		gosType, err := parseGroupOnSystemType(*parameters[2])
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupOnSystemExists(*parameters[0], *parameters[1], gosType, *parameters[3], *parameters[4]), nil
+	})
+}
+
+func (a *groupOnSystemExists) TypeID() string {
+	return "groupOnSystemExists"
+}
+
+func (a *groupOnSystemExists) Parameters() []*string {
+	return []*string{&a.systemUUID, &a.nameInSystem, action.Ptr(a.gosType.String()), &a.displayName, &a.ownerUUID}
+}
+
+func (a *groupOnSystemExists) References() []action.Reference {
+	return []action.Reference{{Kind: systemKind, UUID: a.systemUUID}, {Kind: groupKind, UUID: a.ownerUUID}}
+}
+
+func (a *groupOnSystemExists) Touches() []string {
+	if a.system == nil {
+		return nil
+	}
+	return []string{a.systemUUID, *a.system.GetOwner().GetUuid(), a.ownerUUID}
+}
+
+// findGroupOnSystem returns the group on system with the given name on the system, or nil when no
+// such group on system exists.
+func findGroupOnSystem(ctx context.Context, env *action.Environment, system models.ProvisioningProvisionedSystemable, nameInSystem string) (models.ProvisioningGroupOnSystemable, error) {
+	systemID, err := action.SelfID(system)
+	if err != nil {
+		return nil, err
+	}
+	groupsOnSystem, err := env.Account1.Client.System().BySystemidInt64(systemID).Group().Get(ctx, &keyhubsystem.ItemGroupRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubsystem.ItemGroupRequestBuilderGetQueryParameters{
+			NameInSystem: []string{nameInSystem},
+		},
+	})
+	if err != nil {
+		return nil, action.KeyHubError(err)
+	}
+	if len(groupsOnSystem.GetItems()) == 0 {
+		return nil, nil
+	}
+	return groupsOnSystem.GetItems()[0], nil
+}
+
+// groupOnSystemKey returns the key of the group on system in the model: its UUID when it exists, or
+// its name on the system when it is created by the plan.
+func groupOnSystemKey(systemUUID string, nameInSystem string, gos models.ProvisioningGroupOnSystemable) string {
+	if gos != nil {
+		return *gos.GetUuid()
+	}
+	return systemUUID + "/" + nameInSystem
+}
+
+// observeGroupOnSystem records whether the group on system exists and, when it does, its owner.
+func observeGroupOnSystem(model *action.Model, systemUUID string, nameInSystem string, gos models.ProvisioningGroupOnSystemable) {
+	model.SetGroupOnSystemExists(systemUUID, nameInSystem, gos != nil)
+	if gos != nil {
+		model.SetName(*gos.GetUuid(), *gos.GetNameInSystem())
+		model.SetName(*gos.GetOwner().GetUuid(), *gos.GetOwner().GetName())
+		model.SetGroupOnSystemOwner(*gos.GetUuid(), *gos.GetOwner().GetUuid())
+	}
+}
+
+func (a *groupOnSystemExists) Init(ctx context.Context, env *action.Environment) error {
+	system, err := readSystem(ctx, env, a.systemUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read system with uuid %s: %s", a.systemUUID, action.KeyHubError(err))
+	}
+	a.system = system
+
+	gos, err := findGroupOnSystem(ctx, env, system, a.nameInSystem)
+	if err != nil {
+		return fmt.Errorf("unable to read group on system with name %s: %s", a.nameInSystem, err)
+	}
+	a.gos = gos
+
+	owner, err := readGroup(ctx, env, a.ownerUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.ownerUUID, action.KeyHubError(err))
+	}
+	a.owner = owner
+	a.ownerUUID = *owner.GetUuid()
+	return nil
+}
+
+func (a *groupOnSystemExists) IsSatisfied() bool {
+	return a.gos != nil
+}
+
+func (a *groupOnSystemExists) Requires3() bool {
+	return false
+}
+
+func (a *groupOnSystemExists) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *groupOnSystemExists) Execute(ctx context.Context, env *action.Environment) error {
+	createReq := models.NewRequestCreateGroupOnSystemRequest()
+	createReq.SetGroup(a.owner)
+	createReq.SetSystem(a.system)
+	createReq.SetNameInSystem(&a.nameInSystem)
+	createReq.SetDisplayName(&a.displayName)
+	createReq.SetGroupOnSystemType(&a.gosType)
+	createReq.SetComment(action.Ptr("automation groupOnSystemExists"))
+	err := submitAndAccept(ctx, createReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to create group on system in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupOnSystemExists) Observe(model *action.Model) {
+	model.ObserveGroup(a.owner)
+	model.SetName(a.systemUUID, *a.system.GetName())
+	model.SetName(*a.system.GetOwner().GetUuid(), *a.system.GetOwner().GetName())
+	model.SetSystemOwner(a.systemUUID, *a.system.GetOwner().GetUuid())
+	model.SetGroupOnSystemExists(a.systemUUID, a.nameInSystem, a.gos != nil)
+}
+
+func (a *groupOnSystemExists) Simulate(env *action.Environment, model *action.Model) error {
+	if model.GroupOnSystemExists(a.systemUUID, a.nameInSystem) {
+		return fmt.Errorf("group on system %s already exists on %s", a.nameInSystem, model.Name(a.systemUUID))
+	}
+	err := requireManager(model, a.ownerUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, model.SystemOwner(a.systemUUID), uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetGroupOnSystemExists(a.systemUUID, a.nameInSystem, true)
+	model.SetGroupOnSystemOwner(groupOnSystemKey(a.systemUUID, a.nameInSystem, a.gos), a.ownerUUID)
+	return nil
+}
+
+func (a *groupOnSystemExists) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.ownerUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+		NewAccountInGroup(*env.Account2.Account.GetUuid(), *a.system.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*groupOnSystemExists) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupOnSystemExists) Revert() action.AutomationAction {
+	return NewGroupOnSystemNotExists(a.systemUUID, a.nameInSystem)
+}
+
+func (a *groupOnSystemExists) Progress() string {
+	return fmt.Sprintf("Creating %s", a.displayName)
+}
+
+func (a *groupOnSystemExists) String() string {
+	systemName := a.systemUUID
+	if a.system != nil {
+		systemName = *a.system.GetName()
+	}
+	ownerName := a.ownerUUID
+	if a.owner != nil {
+		ownerName = *a.owner.GetName()
+	}
+	return fmt.Sprintf("Create group on system '%s' (%s) on '%s' owned by '%s'", a.nameInSystem, a.displayName, systemName, ownerName)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupOnSystemNotExists ensures no group on system with the given name exists on the provisioned
+// system. The group on system is removed by a manager of the owner of the system.
+type groupOnSystemNotExists struct {
+	systemUUID   string
+	nameInSystem string
+	system       models.ProvisioningProvisionedSystemable
+	gos          models.ProvisioningGroupOnSystemable
+}
+
+func NewGroupOnSystemNotExists(systemUUID string, nameInSystem string) action.AutomationAction {
+	return &groupOnSystemNotExists{
+		systemUUID:   systemUUID,
+		nameInSystem: nameInSystem,
+	}
+}
+
+func init() {
+	action.Register("groupOnSystemNotExists", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupOnSystemNotExists(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *groupOnSystemNotExists) TypeID() string {
+	return "groupOnSystemNotExists"
+}
+
+func (a *groupOnSystemNotExists) Parameters() []*string {
+	return []*string{&a.systemUUID, &a.nameInSystem}
+}
+
+func (a *groupOnSystemNotExists) References() []action.Reference {
+	return []action.Reference{{Kind: systemKind, UUID: a.systemUUID}}
+}
+
+func (a *groupOnSystemNotExists) Touches() []string {
+	if a.gos == nil {
+		return nil
+	}
+	return []string{a.systemUUID, *a.system.GetOwner().GetUuid(), *a.gos.GetOwner().GetUuid()}
+}
+
+func (a *groupOnSystemNotExists) Init(ctx context.Context, env *action.Environment) error {
+	system, err := readSystem(ctx, env, a.systemUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read system with uuid %s: %s", a.systemUUID, action.KeyHubError(err))
+	}
+	a.system = system
+
+	gos, err := findGroupOnSystem(ctx, env, system, a.nameInSystem)
+	if err != nil {
+		return fmt.Errorf("unable to read group on system with name %s: %s", a.nameInSystem, err)
+	}
+	a.gos = gos
+	return nil
+}
+
+func (a *groupOnSystemNotExists) IsSatisfied() bool {
+	return a.gos == nil
+}
+
+func (a *groupOnSystemNotExists) Requires3() bool {
+	return false
+}
+
+func (a *groupOnSystemNotExists) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *groupOnSystemNotExists) Execute(ctx context.Context, env *action.Environment) error {
+	systemID, err := action.SelfID(a.system)
+	if err != nil {
+		return fmt.Errorf("invalid system in '%s': %s", a.String(), err)
+	}
+	if a.gos == nil {
+		// The group on system did not exist yet when the plan was made, e.g. when reverting its
+		// creation.
+		a.gos, err = findGroupOnSystem(ctx, env, a.system, a.nameInSystem)
+		if err != nil {
+			return fmt.Errorf("unable to read group on system in '%s': %s", a.String(), err)
+		}
+		if a.gos == nil {
+			return nil
+		}
+	}
+	gosID, err := action.SelfID(a.gos)
+	if err != nil {
+		return fmt.Errorf("invalid group on system in '%s': %s", a.String(), err)
+	}
+	err = env.Account1.Client.System().BySystemidInt64(systemID).Group().ByGroupidInt64(gosID).Delete(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot remove group on system in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupOnSystemNotExists) Observe(model *action.Model) {
+	model.SetName(a.systemUUID, *a.system.GetName())
+	model.SetName(*a.system.GetOwner().GetUuid(), *a.system.GetOwner().GetName())
+	model.SetSystemOwner(a.systemUUID, *a.system.GetOwner().GetUuid())
+	observeGroupOnSystem(model, a.systemUUID, a.nameInSystem, a.gos)
+}
+
+func (a *groupOnSystemNotExists) Simulate(env *action.Environment, model *action.Model) error {
+	if !model.GroupOnSystemExists(a.systemUUID, a.nameInSystem) {
+		return fmt.Errorf("group on system %s does not exist on %s", a.nameInSystem, model.Name(a.systemUUID))
+	}
+	err := requireManager(model, model.SystemOwner(a.systemUUID), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	model.SetGroupOnSystemExists(a.systemUUID, a.nameInSystem, false)
+	return nil
+}
+
+func (a *groupOnSystemNotExists) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.system.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*groupOnSystemNotExists) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupOnSystemNotExists) Revert() action.AutomationAction {
+	if a.gos == nil {
+		return nil
+	}
+	return NewGroupOnSystemExists(a.systemUUID, a.nameInSystem, *a.gos.GetTypeEscaped(), *a.gos.GetDisplayName(), *a.gos.GetOwner().GetUuid())
+}
+
+func (a *groupOnSystemNotExists) Progress() string {
+	return fmt.Sprintf("Removing %s", a.nameInSystem)
+}
+
+func (a *groupOnSystemNotExists) String() string {
+	systemName := a.systemUUID
+	if a.system != nil {
+		systemName = *a.system.GetName()
+	}
+	return fmt.Sprintf("Remove group on system '%s' from '%s'", a.nameInSystem, systemName)
+}
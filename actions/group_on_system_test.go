@@ -0,0 +1,99 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func TestGroupProvisionedToGOS(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "existing group on system",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				system := f.AddSystem("Backend", f.AddGroup("System owners"))
+				gos := f.AddGroupOnSystem(system, "cn=ops", "Ops", f.AddGroup("Ops owners"))
+				team := f.AddGroup("Team")
+				return actions.NewGroupProvisionedToGOS(system.UUID, "cn=ops", team.UUID, nil), func(t *testing.T) {
+					if !f.IsProvisioned(gos, team) {
+						t.Errorf("Team is not provisioned to cn=ops")
+					}
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Team' as manager",
+				"Add admin2 to 'Ops owners' as manager",
+				"Provision 'Team' to 'cn=ops' on 'Backend'",
+				"Remove admin2 from 'Ops owners'",
+				"Remove admin1 from 'Team'",
+			},
+		},
+	})
+}
+
+func TestGroupOnSystemExists(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "create",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				system := f.AddSystem("Backend", f.AddGroup("System owners"))
+				owners := f.AddGroup("Ops owners")
+				return actions.NewGroupOnSystemExists(system.UUID, "cn=ops", models.POSIX_GROUP_PROVISIONINGGROUPONSYSTEMTYPE, "Ops", owners.UUID), func(t *testing.T) {
+					gos := f.GroupOnSystemByName(system, "cn=ops")
+					if gos == nil {
+						t.Fatalf("cn=ops was not created on Backend")
+					}
+					if f.GroupOnSystemOwner(gos) != owners {
+						t.Errorf("cn=ops is not owned by Ops owners")
+					}
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Ops owners' as manager",
+				"Add admin2 to 'System owners' as manager",
+				"Create group on system 'cn=ops' (Ops) on 'Backend' owned by 'Ops owners'",
+				"Remove admin2 from 'System owners'",
+				"Remove admin1 from 'Ops owners'",
+			},
+		},
+		{
+			// The group on system does not exist yet when the plan is collected.
+			name: "create and provision",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				system := f.AddSystem("Backend", f.AddGroup("System owners"))
+				owners := f.AddGroup("Ops owners")
+				team := f.AddGroup("Team")
+				return action.NewSequence("Migrate Team",
+						actions.NewGroupOnSystemExists(system.UUID, "cn=ops", models.POSIX_GROUP_PROVISIONINGGROUPONSYSTEMTYPE, "Ops", owners.UUID),
+						actions.NewGroupProvisionedToGOS(system.UUID, "cn=ops", team.UUID, &owners.UUID),
+					), func(t *testing.T) {
+						gos := f.GroupOnSystemByName(system, "cn=ops")
+						if gos == nil {
+							t.Fatalf("cn=ops was not created on Backend")
+						}
+						if !f.IsProvisioned(gos, team) {
+							t.Errorf("Team is not provisioned to cn=ops")
+						}
+						f.checkRights(t, owners, f.admin2, nil)
+					}
+			},
+			plan: []string{
+				"Add admin1 to 'Ops owners' as manager",
+				"Add admin2 to 'System owners' as manager",
+				"Create group on system 'cn=ops' (Ops) on 'Backend' owned by 'Ops owners'",
+				"Remove admin2 from 'System owners'",
+				"Remove admin1 from 'Ops owners'",
+				"Add admin1 to 'Team' as manager",
+				"Add admin2 to 'Ops owners' as manager",
+				"Provision 'Team' to 'cn=ops' on 'Backend'",
+				"Remove admin2 from 'Ops owners'",
+				"Remove admin1 from 'Team'",
+			},
+		},
+	})
+}
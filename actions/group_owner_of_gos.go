@@ -8,9 +8,7 @@ import (
 	"fmt"
 
 	"github.com/topicuskeyhub/automation-framework/action"
-	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
 	"github.com/topicuskeyhub/sdk-go/models"
-	keyhubsystem "github.com/topicuskeyhub/sdk-go/system"
 )
 
 type groupOwnerOfGOS struct {
@@ -30,6 +28,16 @@ func NewGroupOwnerOfGOS(systemUUID string, gosNameInSystem string, groupUUID str
 	}
 }
 
+func init() {
+	action.Register("groupOwnerOfGOS", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 3)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupOwnerOfGOS(*parameters[0], *parameters[1], *parameters[2]), nil
+	})
+}
+
 func (a *groupOwnerOfGOS) TypeID() string {
 	return "groupOwnerOfGOS"
 }
@@ -38,42 +46,41 @@ func (a *groupOwnerOfGOS) Parameters() []*string {
 	return []*string{&a.systemUUID, &a.gosNameInSystem, &a.groupUUID}
 }
 
-func (a *groupOwnerOfGOS) Init(ctx context.Context, env *action.Environment) {
-	system, err := action.First[models.ProvisioningProvisionedSystemable](env.Account1.Client.System().Get(ctx, &keyhubsystem.SystemRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubsystem.SystemRequestBuilderGetQueryParameters{
-			Uuid: []string{a.systemUUID},
-		},
-	}))
+func (a *groupOwnerOfGOS) References() []action.Reference {
+	return []action.Reference{{Kind: systemKind, UUID: a.systemUUID}, {Kind: groupKind, UUID: a.groupUUID}}
+}
+
+func (a *groupOwnerOfGOS) Touches() []string {
+	if a.gos == nil {
+		return nil
+	}
+	return []string{a.systemUUID, a.groupUUID, *a.gos.GetOwner().GetUuid()}
+}
+
+func (a *groupOwnerOfGOS) Init(ctx context.Context, env *action.Environment) error {
+	system, err := readSystem(ctx, env, a.systemUUID)
 	if err != nil {
-		action.Abort(a, "unable to read system with uuid %s: %s", a.systemUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read system with uuid %s: %s", a.systemUUID, action.KeyHubError(err))
 	}
 	a.system = system
 
-	gos, err := action.First[models.ProvisioningGroupOnSystemable](env.Account1.Client.System().
-		BySystemidInt64(*action.Self(system).GetId()).Group().Get(ctx, &keyhubsystem.ItemGroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubsystem.ItemGroupRequestBuilderGetQueryParameters{
-			NameInSystem: []string{a.gosNameInSystem},
-		},
-	}))
+	gos, err := findGroupOnSystem(ctx, env, system, a.gosNameInSystem)
 	if err != nil {
-		action.Abort(a, "unable to read group on system with name %s: %s", a.gosNameInSystem, action.KeyHubError(err))
+		return fmt.Errorf("unable to read group on system with name %s: %s", a.gosNameInSystem, err)
 	}
 	a.gos = gos
 
-	group, err := action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
-		QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
-			Uuid: []string{a.groupUUID},
-		},
-	}))
+	group, err := readGroup(ctx, env, a.groupUUID)
 	if err != nil {
-		action.Abort(a, "unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
 	}
 	a.group = group
-
+	a.groupUUID = *group.GetUuid()
+	return nil
 }
 
 func (a *groupOwnerOfGOS) IsSatisfied() bool {
-	return *a.gos.GetOwner().GetUuid() == a.groupUUID
+	return a.gos != nil && *a.gos.GetOwner().GetUuid() == a.groupUUID
 }
 
 func (a *groupOwnerOfGOS) Requires3() bool {
@@ -85,6 +92,21 @@ func (a *groupOwnerOfGOS) AllowGlobalOptimization() bool {
 }
 
 func (a *groupOwnerOfGOS) Execute(ctx context.Context, env *action.Environment) error {
+	if a.gos == nil {
+		// The group on system did not exist yet when the plan was made, it is created earlier in
+		// the plan.
+		gos, err := findGroupOnSystem(ctx, env, a.system, a.gosNameInSystem)
+		if err != nil {
+			return fmt.Errorf("unable to read group on system in '%s': %s", a.String(), err)
+		}
+		if gos == nil {
+			return fmt.Errorf("group on system does not exist in '%s'", a.String())
+		}
+		a.gos = gos
+	}
+	if *a.gos.GetOwner().GetUuid() == a.groupUUID {
+		return nil
+	}
 	newTransferOwner := models.NewRequestTransferGroupOnSystemOwnershipRequest()
 	newTransferOwner.SetGroupOnSystem(a.gos)
 	newTransferOwner.SetGroup(a.group)
@@ -96,18 +118,50 @@ func (a *groupOwnerOfGOS) Execute(ctx context.Context, env *action.Environment)
 	return nil
 }
 
+func (a *groupOwnerOfGOS) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	observeGroupOnSystem(model, a.systemUUID, a.gosNameInSystem, a.gos)
+}
+
+func (a *groupOwnerOfGOS) Simulate(env *action.Environment, model *action.Model) error {
+	if !model.GroupOnSystemExists(a.systemUUID, a.gosNameInSystem) {
+		return fmt.Errorf("group on system %s does not exist on %s", a.gosNameInSystem, model.Name(a.systemUUID))
+	}
+	gosKey := groupOnSystemKey(a.systemUUID, a.gosNameInSystem, a.gos)
+	err := requireManager(model, model.GroupOnSystemOwner(gosKey), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, a.groupUUID, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetGroupOnSystemOwner(gosKey, a.groupUUID)
+	return nil
+}
+
+// Setup makes account 1 a manager of the current owner of the group on system. When the group on
+// system does not exist yet, its owner is set up by the action creating it.
 func (a *groupOwnerOfGOS) Setup(env *action.Environment) []action.AutomationAction {
-	return []action.AutomationAction{
-		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.gos.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	ret := []action.AutomationAction{
 		NewAccountInGroup(*env.Account2.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
 	}
+	if a.gos != nil {
+		ret = append(ret, NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.gos.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)))
+	}
+	return ret
 }
 
 func (*groupOwnerOfGOS) Perform(env *action.Environment) []action.AutomationAction {
 	return make([]action.AutomationAction, 0)
 }
 
+// Revert transfers the ownership back. A group on system created by the plan is removed by the
+// revert of its creation instead.
 func (a *groupOwnerOfGOS) Revert() action.AutomationAction {
+	if a.gos == nil {
+		return nil
+	}
 	return NewGroupOwnerOfGOS(a.systemUUID, a.gosNameInSystem, *a.gos.GetOwner().GetUuid())
 }
 
@@ -0,0 +1,229 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupProvisionedToGOS ensures the group is one of the provisioned groups of the group on system,
+// so its members are provisioned into that group on the system. The optional owner is the owner of
+// the group on system when it is created earlier in the plan.
+type groupProvisionedToGOS struct {
+	systemUUID      string
+	gosNameInSystem string
+	groupUUID       string
+	ownerUUID       *string
+	system          models.ProvisioningProvisionedSystemable
+	gos             models.ProvisioningGroupOnSystemable
+	group           models.GroupGroupable
+	provGroup       models.GroupProvisioningGroupable
+}
+
+func NewGroupProvisionedToGOS(systemUUID string, gosNameInSystem string, groupUUID string, ownerUUID *string) action.AutomationAction {
+	return &groupProvisionedToGOS{
+		systemUUID:      systemUUID,
+		gosNameInSystem: gosNameInSystem,
+		groupUUID:       groupUUID,
+		ownerUUID:       ownerUUID,
+	}
+}
+
+func init() {
+	action.Register("groupProvisionedToGOS", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 4, 3)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupProvisionedToGOS(*parameters[0], *parameters[1], *parameters[2], parameters[3]), nil
+	})
+}
+
+func (a *groupProvisionedToGOS) TypeID() string {
+	return "groupProvisionedToGOS"
+}
+
+func (a *groupProvisionedToGOS) Parameters() []*string {
+	return []*string{&a.systemUUID, &a.gosNameInSystem, &a.groupUUID, a.ownerUUID}
+}
+
+func (a *groupProvisionedToGOS) References() []action.Reference {
+	return []action.Reference{{Kind: systemKind, UUID: a.systemUUID}, {Kind: groupKind, UUID: a.groupUUID}}
+}
+
+func (a *groupProvisionedToGOS) Touches() []string {
+	if a.gos == nil {
+		return nil
+	}
+	return []string{a.systemUUID, a.groupUUID, *a.gos.GetOwner().GetUuid()}
+}
+
+func (a *groupProvisionedToGOS) Init(ctx context.Context, env *action.Environment) error {
+	system, gos, err := readGroupOnSystem(ctx, env, a.systemUUID, a.gosNameInSystem)
+	if err != nil {
+		return err
+	}
+	a.system = system
+	a.gos = gos
+
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+
+	provGroup, err := findProvisioningGroup(ctx, env, system, gos, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read provisioned groups of %s: %s", a.gosNameInSystem, err)
+	}
+	a.provGroup = provGroup
+	return nil
+}
+
+// readGroupOnSystem reads the system with the given UUID and its group on system with the given
+// name in the system. The group on system is nil when it does not exist (yet).
+func readGroupOnSystem(ctx context.Context, env *action.Environment, systemUUID string, nameInSystem string) (models.ProvisioningProvisionedSystemable, models.ProvisioningGroupOnSystemable, error) {
+	system, err := readSystem(ctx, env, systemUUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read system with uuid %s: %s", systemUUID, action.KeyHubError(err))
+	}
+	gos, err := findGroupOnSystem(ctx, env, system, nameInSystem)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read group on system with name %s: %s", nameInSystem, err)
+	}
+	return system, gos, nil
+}
+
+// findProvisioningGroup returns the link between the group on system and the group, or nil when
+// the group is not provisioned to the group on system or the group on system does not exist.
+func findProvisioningGroup(ctx context.Context, env *action.Environment, system models.ProvisioningProvisionedSystemable,
+	gos models.ProvisioningGroupOnSystemable, groupUUID string) (models.GroupProvisioningGroupable, error) {
+	if gos == nil {
+		return nil, nil
+	}
+	systemID, err := action.SelfID(system)
+	if err != nil {
+		return nil, err
+	}
+	gosID, err := action.SelfID(gos)
+	if err != nil {
+		return nil, err
+	}
+	provGroups, err := env.Account1.Client.System().BySystemidInt64(systemID).Group().ByGroupidInt64(gosID).Provgroup().Get(ctx, nil)
+	if err != nil {
+		return nil, action.KeyHubError(err)
+	}
+	for _, p := range provGroups.GetItems() {
+		if *p.GetGroup().GetUuid() == groupUUID {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (a *groupProvisionedToGOS) IsSatisfied() bool {
+	return a.provGroup != nil
+}
+
+func (a *groupProvisionedToGOS) Requires3() bool {
+	return false
+}
+
+func (a *groupProvisionedToGOS) AllowGlobalOptimization() bool {
+	return true
+}
+
+func (a *groupProvisionedToGOS) Execute(ctx context.Context, env *action.Environment) error {
+	if a.gos == nil {
+		// The group on system did not exist yet when the plan was made, it is created earlier in
+		// the plan.
+		gos, err := findGroupOnSystem(ctx, env, a.system, a.gosNameInSystem)
+		if err != nil {
+			return fmt.Errorf("unable to read group on system in '%s': %s", a.String(), err)
+		}
+		if gos == nil {
+			return fmt.Errorf("group on system does not exist in '%s'", a.String())
+		}
+		a.gos = gos
+	}
+	grantReq := models.NewRequestGrantGroupOnSystemRequest()
+	grantReq.SetGroup(a.group)
+	grantReq.SetGroupOnSystem(a.gos)
+	grantReq.SetComment(action.Ptr("automation groupProvisionedToGOS"))
+	err := submitAndAccept(ctx, grantReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to provision group in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *groupProvisionedToGOS) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	observeGroupOnSystem(model, a.systemUUID, a.gosNameInSystem, a.gos)
+	model.SetProvisioned(groupOnSystemKey(a.systemUUID, a.gosNameInSystem, a.gos), a.groupUUID, a.provGroup != nil)
+}
+
+func (a *groupProvisionedToGOS) Simulate(env *action.Environment, model *action.Model) error {
+	if !model.GroupOnSystemExists(a.systemUUID, a.gosNameInSystem) {
+		return fmt.Errorf("group on system %s does not exist on %s", a.gosNameInSystem, model.Name(a.systemUUID))
+	}
+	gosKey := groupOnSystemKey(a.systemUUID, a.gosNameInSystem, a.gos)
+	err := requireManager(model, a.groupUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, model.GroupOnSystemOwner(gosKey), uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetProvisioned(gosKey, a.groupUUID, true)
+	return nil
+}
+
+// Setup makes account 1 a manager of the group and account 2 a manager of the owner of the group on
+// system. When the group on system does not exist yet, the owner passed to the action is used.
+func (a *groupProvisionedToGOS) Setup(env *action.Environment) []action.AutomationAction {
+	ret := []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+	if a.gos != nil {
+		ret = append(ret, NewAccountInGroup(*env.Account2.Account.GetUuid(), *a.gos.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)))
+	} else if a.ownerUUID != nil {
+		ret = append(ret, NewAccountInGroup(*env.Account2.Account.GetUuid(), *a.ownerUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)))
+	}
+	return ret
+}
+
+func (*groupProvisionedToGOS) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupProvisionedToGOS) Revert() action.AutomationAction {
+	return NewGroupNotProvisionedToGOS(a.systemUUID, a.gosNameInSystem, a.groupUUID)
+}
+
+func (a *groupProvisionedToGOS) Progress() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Provisioning %s", groupName)
+}
+
+func (a *groupProvisionedToGOS) String() string {
+	systemName := a.systemUUID
+	if a.system != nil {
+		systemName = *a.system.GetName()
+	}
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Provision '%s' to '%s' on '%s'", groupName, a.gosNameInSystem, systemName)
+}
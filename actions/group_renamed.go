@@ -0,0 +1,166 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// groupRenamed ensures the group has the given name.
+type groupRenamed struct {
+	groupUUID string
+	name      string
+	group     models.GroupGroupable
+}
+
+func NewGroupRenamed(groupUUID string, name string) action.AutomationAction {
+	return &groupRenamed{
+		groupUUID: groupUUID,
+		name:      name,
+	}
+}
+
+func init() {
+	action.Register("groupRenamed", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupRenamed(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *groupRenamed) TypeID() string {
+	return "groupRenamed"
+}
+
+func (a *groupRenamed) Parameters() []*string {
+	return []*string{&a.groupUUID, &a.name}
+}
+
+func (a *groupRenamed) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.groupUUID}, {Kind: groupKind, UUID: GroupByName(a.name)}}
+}
+
+func (a *groupRenamed) Touches() []string {
+	ret := []string{a.groupUUID, GroupByName(a.name)}
+	if a.group != nil {
+		ret = append(ret, GroupByName(*a.group.GetName()))
+	}
+	return ret
+}
+
+func (a *groupRenamed) Init(ctx context.Context, env *action.Environment) error {
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	if len(group.GetLinks()) == 0 {
+		// The group referred to by its old name no longer exists, it may have been renamed
+		// already.
+		renamed, err := findGroupByName(ctx, env, a.name)
+		if err != nil {
+			return fmt.Errorf("unable to read group with name %s: %s", a.name, err)
+		}
+		if renamed != nil {
+			group = renamed
+		}
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+	return nil
+}
+
+func (a *groupRenamed) IsSatisfied() bool {
+	return *a.group.GetName() == a.name
+}
+
+func (a *groupRenamed) Requires3() bool {
+	return false
+}
+
+func (a *groupRenamed) AllowGlobalOptimization() bool {
+	return false
+}
+
+// updateGroup reads the group again, applies the change and stores it as account 1, which must be
+// a manager of the group. The group is read again so the cached group is never modified.
+func updateGroup(ctx context.Context, env *action.Environment, group models.GroupGroupable, change func(group models.GroupGroupable)) error {
+	groupID, err := action.SelfID(group)
+	if err != nil {
+		return err
+	}
+	current, err := env.Account1.Client.Group().ByGroupidInt64(groupID).Get(ctx, nil)
+	if err != nil {
+		return action.KeyHubError(err)
+	}
+	change(current)
+	_, err = env.Account1.Client.Group().ByGroupidInt64(groupID).Put(ctx, current, nil)
+	if err != nil {
+		return action.KeyHubError(err)
+	}
+	return nil
+}
+
+func (a *groupRenamed) Execute(ctx context.Context, env *action.Environment) error {
+	err := updateGroup(ctx, env, a.group, func(group models.GroupGroupable) {
+		group.SetName(&a.name)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot rename group in '%s': %s", a.String(), err)
+	}
+	return nil
+}
+
+func (a *groupRenamed) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+}
+
+func (a *groupRenamed) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, a.groupUUID, uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	if model.GroupExists(a.name) {
+		return fmt.Errorf("group %s already exists", a.name)
+	}
+	model.SetGroupExists(model.Name(a.groupUUID), false)
+	model.SetGroupExists(a.name, true)
+	model.SetName(a.groupUUID, a.name)
+	return nil
+}
+
+func (a *groupRenamed) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (a *groupRenamed) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *groupRenamed) Revert() action.AutomationAction {
+	return NewGroupRenamed(a.groupUUID, *a.group.GetName())
+}
+
+func (a *groupRenamed) Progress() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Renaming %s", groupName)
+}
+
+func (a *groupRenamed) String() string {
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Rename group '%s' to '%s'", groupName, a.name)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+)
+
+func TestAccountNotInOU(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "remove account",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				user := f.AddAccount("user")
+				owners := f.AddGroup("OU owners")
+				orgUnit := f.AddOrganizationalUnit("Engineering", owners)
+				f.AddOrganizationalUnitAccount(orgUnit, user)
+				return actions.NewAccountNotInOU(user.UUID, orgUnit.UUID), func(t *testing.T) {
+					if f.InOrganizationalUnit(orgUnit, user) {
+						t.Errorf("user is still in Engineering")
+					}
+					f.checkRights(t, owners, f.admin1, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'OU owners' as manager",
+				"Remove user from 'Engineering'",
+				"Remove admin1 from 'OU owners'",
+			},
+		},
+	})
+}
+
+func TestGroupNotExists(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "remove",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				f.AddGroup("Team")
+				return actions.NewGroupNotExists("Team"), func(t *testing.T) {
+					if f.GroupByName("Team") != nil {
+						t.Errorf("Team was not removed")
+					}
+				}
+			},
+			// The memberships of the removed group are not cleaned up.
+			plan: []string{
+				"Add admin1 to 'Team' as manager",
+				"Add admin2 to 'Team' as manager",
+				"Remove group 'Team'",
+			},
+		},
+		{
+			name: "does not exist",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				return actions.NewGroupNotExists("Team"), func(t *testing.T) {}
+			},
+			plan: []string{},
+		},
+	})
+}
+
+func TestGroupRenamed(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "rename",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				team := f.AddGroup("Team")
+				return actions.NewGroupRenamed(team.UUID, "Platform"), func(t *testing.T) {
+					if f.GroupByName("Platform") != team {
+						t.Errorf("Team was not renamed to Platform")
+					}
+					if f.GroupByName("Team") != nil {
+						t.Errorf("a group named Team still exists")
+					}
+					f.checkRights(t, team, f.admin1, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Team' as manager",
+				"Rename group 'Team' to 'Platform'",
+				"Remove admin1 from 'Team'",
+			},
+		},
+	})
+}
+
+func TestGroupClassification(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "classify unclassified group",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				team := f.AddGroup("Team")
+				confidential := f.AddGroupClassification("Confidential")
+				return actions.NewGroupClassification(team.UUID, confidential.UUID), func(t *testing.T) {
+					if f.GroupClassification(team) != confidential {
+						t.Errorf("Team is not classified as Confidential")
+					}
+					f.checkRights(t, team, f.admin1, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Team' as manager",
+				"Change classification of 'Team' to 'Confidential' (cannot be reverted, the group has no classification)",
+				"Remove admin1 from 'Team'",
+			},
+		},
+	})
+}
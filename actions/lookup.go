@@ -0,0 +1,216 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubaccount "github.com/topicuskeyhub/sdk-go/account"
+	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
+	"github.com/topicuskeyhub/sdk-go/models"
+	keyhuborganizationalunit "github.com/topicuskeyhub/sdk-go/organizationalunit"
+	keyhubsystem "github.com/topicuskeyhub/sdk-go/system"
+)
+
+// The kinds of resources in the cache of the environment, all keyed by UUID.
+const (
+	groupKind             = "group"
+	groupWithAccountsKind = "group+accounts"
+	accountKind           = "account"
+	systemKind            = "system"
+	orgUnitKind           = "organizationalunit"
+)
+
+func init() {
+	action.RegisterPrefetcher(groupKind, func(ctx context.Context, env *action.Environment, uuids []string) error {
+		uuids = withoutGroupNames(uuids)
+		if len(uuids) == 0 {
+			return nil
+		}
+		groups, err := env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
+				Uuid: uuids,
+			},
+		})
+		if err != nil {
+			return action.KeyHubError(err)
+		}
+		for _, g := range groups.GetItems() {
+			env.Cache.Put(groupKind, *g.GetUuid(), g)
+		}
+		return nil
+	})
+	action.RegisterPrefetcher(groupWithAccountsKind, func(ctx context.Context, env *action.Environment, uuids []string) error {
+		uuids = withoutGroupNames(uuids)
+		if len(uuids) == 0 {
+			return nil
+		}
+		groups, err := env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
+				Uuid:       uuids,
+				Additional: []string{"accounts"},
+			},
+		})
+		if err != nil {
+			return action.KeyHubError(err)
+		}
+		for _, g := range groups.GetItems() {
+			env.Cache.Put(groupWithAccountsKind, *g.GetUuid(), g)
+		}
+		return nil
+	})
+	action.RegisterPrefetcher(accountKind, func(ctx context.Context, env *action.Environment, uuids []string) error {
+		accounts, err := env.Account1.Client.Account().Get(ctx, &keyhubaccount.AccountRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubaccount.AccountRequestBuilderGetQueryParameters{
+				Uuid: uuids,
+			},
+		})
+		if err != nil {
+			return action.KeyHubError(err)
+		}
+		for _, a := range accounts.GetItems() {
+			env.Cache.Put(accountKind, *a.GetUuid(), a)
+		}
+		return nil
+	})
+	action.RegisterPrefetcher(systemKind, func(ctx context.Context, env *action.Environment, uuids []string) error {
+		systems, err := env.Account1.Client.System().Get(ctx, &keyhubsystem.SystemRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubsystem.SystemRequestBuilderGetQueryParameters{
+				Uuid: uuids,
+			},
+		})
+		if err != nil {
+			return action.KeyHubError(err)
+		}
+		for _, s := range systems.GetItems() {
+			env.Cache.Put(systemKind, *s.GetUuid(), s)
+		}
+		return nil
+	})
+	action.RegisterPrefetcher(orgUnitKind, func(ctx context.Context, env *action.Environment, uuids []string) error {
+		orgUnits, err := env.Account1.Client.Organizationalunit().Get(ctx, &keyhuborganizationalunit.OrganizationalunitRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhuborganizationalunit.OrganizationalunitRequestBuilderGetQueryParameters{
+				Uuid: uuids,
+			},
+		})
+		if err != nil {
+			return action.KeyHubError(err)
+		}
+		for _, o := range orgUnits.GetItems() {
+			env.Cache.Put(orgUnitKind, *o.GetUuid(), o)
+		}
+		return nil
+	})
+}
+
+// groupNamePrefix marks a group reference by name instead of UUID.
+const groupNamePrefix = "name:"
+
+// GroupByName returns a reference to the group with the given name, that can be passed to every
+// action in place of a group UUID. It allows a plan to refer to a group created by an earlier
+// groupExists action, as the UUID of that group is not known before it is created. Actions resolve
+// the reference to the UUID of the group in Init.
+func GroupByName(name string) string {
+	return groupNamePrefix + name
+}
+
+func groupName(groupUUID string) (string, bool) {
+	return strings.CutPrefix(groupUUID, groupNamePrefix)
+}
+
+// readGroup reads the group with the given UUID through the cache of the environment. For a
+// reference by name to a group that does not exist yet, it returns a group without members, with
+// the reference as UUID. That group has no links, so executing an action on it fails until the group
+// is created.
+func readGroup(ctx context.Context, env *action.Environment, groupUUID string) (models.GroupGroupable, error) {
+	if name, ok := groupName(groupUUID); ok {
+		return readGroupByName(ctx, env, groupKind, name)
+	}
+	return action.Cached(env, groupKind, groupUUID, func() (models.GroupGroupable, error) {
+		return action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
+				Uuid: []string{groupUUID},
+			},
+		}))
+	})
+}
+
+// readGroupWithAccounts reads the group with the given UUID and its members through the cache of
+// the environment. References by name are handled like readGroup does.
+func readGroupWithAccounts(ctx context.Context, env *action.Environment, groupUUID string) (models.GroupGroupable, error) {
+	if name, ok := groupName(groupUUID); ok {
+		return readGroupByName(ctx, env, groupWithAccountsKind, name, "accounts")
+	}
+	return action.Cached(env, groupWithAccountsKind, groupUUID, func() (models.GroupGroupable, error) {
+		return action.First[models.GroupGroupable](env.Account1.Client.Group().Get(ctx, &keyhubgroup.GroupRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubgroup.GroupRequestBuilderGetQueryParameters{
+				Uuid:       []string{groupUUID},
+				Additional: []string{"accounts"},
+			},
+		}))
+	})
+}
+
+func readGroupByName(ctx context.Context, env *action.Environment, kind string, name string, additional ...string) (models.GroupGroupable, error) {
+	return action.Cached(env, kind, GroupByName(name), func() (models.GroupGroupable, error) {
+		group, err := findGroupByName(ctx, env, name, additional...)
+		if err != nil || group != nil {
+			return group, err
+		}
+		accounts := models.NewGroupGroupAccountLinkableWrapper()
+		accounts.SetItems(make([]models.GroupGroupAccountable, 0))
+		additionalObjects := models.NewGroupGroup_additionalObjects()
+		additionalObjects.SetAccounts(accounts)
+		ret := models.NewGroupGroup()
+		ret.SetUuid(action.Ptr(GroupByName(name)))
+		ret.SetName(&name)
+		ret.SetAdditionalObjects(additionalObjects)
+		return ret, nil
+	})
+}
+
+// withoutGroupNames removes the references by name, which cannot be read in bulk by UUID.
+func withoutGroupNames(uuids []string) []string {
+	return slices.DeleteFunc(slices.Clone(uuids), func(uuid string) bool {
+		return strings.HasPrefix(uuid, groupNamePrefix)
+	})
+}
+
+// readAccount reads the account with the given UUID through the cache of the environment.
+func readAccount(ctx context.Context, env *action.Environment, accountUUID string) (models.AuthAccountable, error) {
+	return action.Cached(env, accountKind, accountUUID, func() (models.AuthAccountable, error) {
+		return action.First[models.AuthAccountable](env.Account1.Client.Account().Get(ctx, &keyhubaccount.AccountRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubaccount.AccountRequestBuilderGetQueryParameters{
+				Uuid: []string{accountUUID},
+			},
+		}))
+	})
+}
+
+// readSystem reads the provisioned system with the given UUID through the cache of the
+// environment.
+func readSystem(ctx context.Context, env *action.Environment, systemUUID string) (models.ProvisioningProvisionedSystemable, error) {
+	return action.Cached(env, systemKind, systemUUID, func() (models.ProvisioningProvisionedSystemable, error) {
+		return action.First[models.ProvisioningProvisionedSystemable](env.Account1.Client.System().Get(ctx, &keyhubsystem.SystemRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhubsystem.SystemRequestBuilderGetQueryParameters{
+				Uuid: []string{systemUUID},
+			},
+		}))
+	})
+}
+
+// readOrganizationalUnit reads the organizational unit with the given UUID through the cache of the
+// environment.
+func readOrganizationalUnit(ctx context.Context, env *action.Environment, orgUnitUUID string) (models.OrganizationOrganizationalUnitable, error) {
+	return action.Cached(env, orgUnitKind, orgUnitUUID, func() (models.OrganizationOrganizationalUnitable, error) {
+		return action.First[models.OrganizationOrganizationalUnitable](env.Account1.Client.Organizationalunit().Get(ctx, &keyhuborganizationalunit.OrganizationalunitRequestBuilderGetRequestConfiguration{
+			QueryParameters: &keyhuborganizationalunit.OrganizationalunitRequestBuilderGetQueryParameters{
+				Uuid: []string{orgUnitUUID},
+			},
+		}))
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// transferPlan returns the plan for a transfer from 'Old owners' to 'New owners'.
+func transferPlan(transfer string) []string {
+	return []string{
+		"Add admin1 to 'Old owners' as manager",
+		"Add admin2 to 'New owners' as manager",
+		transfer,
+		"Remove admin2 from 'New owners'",
+		"Remove admin1 from 'Old owners'",
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "system",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				system := f.AddSystem("Backend", f.AddGroup("Old owners"))
+				newOwners := f.AddGroup("New owners")
+				return actions.NewSystemOwnedByGroup(system.UUID, newOwners.UUID), func(t *testing.T) {
+					if f.SystemOwner(system) != newOwners {
+						t.Errorf("Backend is not owned by New owners")
+					}
+				}
+			},
+			plan: transferPlan("Transfer ownership of system 'Backend' to 'New owners'"),
+		},
+		{
+			name: "application",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				client := f.AddClient("Portal", "portal", f.AddGroup("Old owners"))
+				newOwners := f.AddGroup("New owners")
+				return actions.NewClientOwnedByGroup(client.UUID, newOwners.UUID), func(t *testing.T) {
+					if f.ClientOwner(client) != newOwners {
+						t.Errorf("Portal is not owned by New owners")
+					}
+				}
+			},
+			plan: transferPlan("Transfer ownership of application 'Portal' to 'New owners'"),
+		},
+		{
+			name: "service account",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				oldOwners := f.AddGroup("Old owners")
+				serviceAccount := f.AddServiceAccount(f.AddSystem("Backend", oldOwners), "svc-backup", oldOwners)
+				newOwners := f.AddGroup("New owners")
+				return actions.NewServiceAccountAdministeredByGroup(serviceAccount.UUID, newOwners.UUID), func(t *testing.T) {
+					if f.ServiceAccountAdmin(serviceAccount) != newOwners {
+						t.Errorf("svc-backup is not administered by New owners")
+					}
+				}
+			},
+			plan: transferPlan("Transfer administration of service account 'svc-backup' to 'New owners'"),
+		},
+	})
+}
+
+func TestClientPermissionGranted(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "on group",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				client := f.AddClient("Portal", "portal", f.AddGroup("App owners"))
+				consumers := f.AddGroup("Consumers")
+				permission := models.GROUP_READ_CONTENTS_CLIENTOAUTH2CLIENTPERMISSIONTYPE
+				return actions.NewClientPermissionGranted(client.UUID, permission, &consumers.UUID, nil), func(t *testing.T) {
+					if !f.HasClientPermission(client, permission, consumers, nil) {
+						t.Errorf("Portal has no permission on Consumers")
+					}
+					f.checkRights(t, consumers, f.admin2, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'App owners' as manager",
+				"Add admin2 to 'Consumers' as manager",
+				"Grant " + models.GROUP_READ_CONTENTS_CLIENTOAUTH2CLIENTPERMISSIONTYPE.String() + " on 'Consumers' to application 'Portal'",
+				"Remove admin2 from 'Consumers'",
+				"Remove admin1 from 'App owners'",
+			},
+		},
+	})
+}
+
+func TestClientPermissionNotGranted(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "on group",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				owners := f.AddGroup("App owners")
+				client := f.AddClient("Portal", "portal", owners)
+				consumers := f.AddGroup("Consumers")
+				permission := models.GROUP_READ_CONTENTS_CLIENTOAUTH2CLIENTPERMISSIONTYPE
+				f.AddClientPermission(client, permission, consumers, nil)
+				return actions.NewClientPermissionNotGranted(client.UUID, permission, &consumers.UUID, nil), func(t *testing.T) {
+					if f.HasClientPermission(client, permission, consumers, nil) {
+						t.Errorf("Portal still has permission on Consumers")
+					}
+					f.checkRights(t, owners, f.admin1, nil)
+					f.checkRights(t, consumers, f.admin2, nil)
+					if len(f.Requests()) != 0 {
+						t.Errorf("revoking the permission submitted %d requests, want none", len(f.Requests()))
+					}
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'App owners' as manager",
+				"Revoke " + models.GROUP_READ_CONTENTS_CLIENTOAUTH2CLIENTPERMISSIONTYPE.String() + " on 'Consumers' from application 'Portal'",
+				"Remove admin1 from 'App owners'",
+			},
+		},
+		{
+			name: "not granted",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				client := f.AddClient("Portal", "portal", f.AddGroup("App owners"))
+				consumers := f.AddGroup("Consumers")
+				permission := models.GROUP_READ_CONTENTS_CLIENTOAUTH2CLIENTPERMISSIONTYPE
+				return actions.NewClientPermissionNotGranted(client.UUID, permission, &consumers.UUID, nil), func(t *testing.T) {
+					if f.HasClientPermission(client, permission, consumers, nil) {
+						t.Errorf("Portal has permission on Consumers")
+					}
+				}
+			},
+			plan: []string{},
+		},
+	})
+}
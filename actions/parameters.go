@@ -0,0 +1,74 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func parseRights(value *string) (*models.GroupGroupRights, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch *value {
+	case "manager":
+		return action.Ptr(models.MANAGER_GROUPGROUPRIGHTS), nil
+	case "member":
+		return action.Ptr(models.NORMAL_GROUPGROUPRIGHTS), nil
+	}
+	return nil, fmt.Errorf("invalid group rights '%s', expected 'manager' or 'member'", *value)
+}
+
+func parseAuthorizationType(value string) (models.RequestAuthorizingGroupType, error) {
+	authType, err := models.ParseRequestAuthorizingGroupType(value)
+	if err != nil {
+		return 0, err
+	}
+	return *authType.(*models.RequestAuthorizingGroupType), nil
+}
+
+func parseVaultRecordMode(value string) (models.VaultMoveVaultRecordAction, error) {
+	switch value {
+	case "move":
+		return models.MOVE_VAULTMOVEVAULTRECORDACTION, nil
+	case "copy":
+		return models.COPY_VAULTMOVEVAULTRECORDACTION, nil
+	}
+	return 0, fmt.Errorf("invalid vault record mode '%s', expected 'move' or 'copy'", value)
+}
+
+func describeVaultRecordMode(mode models.VaultMoveVaultRecordAction) string {
+	if mode == models.COPY_VAULTMOVEVAULTRECORDACTION {
+		return "copy"
+	}
+	return "move"
+}
+
+func parseGroupOnSystemType(value string) (models.ProvisioningGroupOnSystemType, error) {
+	gosType, err := models.ParseProvisioningGroupOnSystemType(value)
+	if err != nil {
+		return 0, err
+	}
+	return *gosType.(*models.ProvisioningGroupOnSystemType), nil
+}
+
+func parseClientPermissionType(value string) (models.ClientOAuth2ClientPermissionType, error) {
+	permType, err := models.ParseClientOAuth2ClientPermissionType(value)
+	if err != nil {
+		return 0, err
+	}
+	return *permType.(*models.ClientOAuth2ClientPermissionType), nil
+}
+
+// checkPermissionTarget verifies exactly one of the group and system of a client permission is
+// given.
+func checkPermissionTarget(groupUUID *string, systemUUID *string) error {
+	if (groupUUID == nil) == (systemUUID == nil) {
+		return fmt.Errorf("a client permission requires either a group or a system")
+	}
+	return nil
+}
@@ -18,7 +18,11 @@ func submitAndAccept(ctx context.Context, request models.RequestModificationRequ
 
 	r.SetStatus(action.Ptr(models.ALLOWED_REQUESTMODIFICATIONREQUESTSTATUS))
 	r.SetFeedback(request.GetComment())
-	_, err = accepter.Client.Request().ByRequestidInt64(*action.Self(r).GetId()).Put(ctx, r, nil)
+	requestID, err := action.SelfID(r)
+	if err != nil {
+		return fmt.Errorf("invalid request: %s", err)
+	}
+	_, err = accepter.Client.Request().ByRequestidInt64(requestID).Put(ctx, r, nil)
 	if err != nil {
 		return fmt.Errorf("cannot handle request: %s", action.KeyHubError(err))
 	}
@@ -0,0 +1,156 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+	keyhubserviceaccount "github.com/topicuskeyhub/sdk-go/serviceaccount"
+)
+
+type serviceAccountAdministeredByGroup struct {
+	serviceAccountUUID string
+	groupUUID          string
+	serviceAccount     models.ServiceaccountServiceAccountable
+	group              models.GroupGroupable
+}
+
+func NewServiceAccountAdministeredByGroup(serviceAccountUUID string, groupUUID string) action.AutomationAction {
+	return &serviceAccountAdministeredByGroup{
+		serviceAccountUUID: serviceAccountUUID,
+		groupUUID:          groupUUID,
+	}
+}
+
+func init() {
+	action.Register("serviceAccountAdministeredByGroup", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewServiceAccountAdministeredByGroup(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *serviceAccountAdministeredByGroup) TypeID() string {
+	return "serviceAccountAdministeredByGroup"
+}
+
+func (a *serviceAccountAdministeredByGroup) Parameters() []*string {
+	return []*string{&a.serviceAccountUUID, &a.groupUUID}
+}
+
+func (a *serviceAccountAdministeredByGroup) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.groupUUID}}
+}
+
+func (a *serviceAccountAdministeredByGroup) Touches() []string {
+	if a.serviceAccount == nil {
+		return nil
+	}
+	return []string{a.serviceAccountUUID, a.groupUUID, *a.serviceAccount.GetTechnicalAdministrator().GetUuid()}
+}
+
+func (a *serviceAccountAdministeredByGroup) Init(ctx context.Context, env *action.Environment) error {
+	serviceAccount, err := action.First[models.ServiceaccountServiceAccountable](env.Account1.Client.Serviceaccount().Get(ctx, &keyhubserviceaccount.ServiceaccountRequestBuilderGetRequestConfiguration{
+		QueryParameters: &keyhubserviceaccount.ServiceaccountRequestBuilderGetQueryParameters{
+			Uuid: []string{a.serviceAccountUUID},
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("unable to read service account with uuid %s: %s", a.serviceAccountUUID, action.KeyHubError(err))
+	}
+	a.serviceAccount = serviceAccount
+
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+	return nil
+}
+
+func (a *serviceAccountAdministeredByGroup) IsSatisfied() bool {
+	return *a.serviceAccount.GetTechnicalAdministrator().GetUuid() == a.groupUUID
+}
+
+func (a *serviceAccountAdministeredByGroup) Requires3() bool {
+	return false
+}
+
+func (a *serviceAccountAdministeredByGroup) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *serviceAccountAdministeredByGroup) Execute(ctx context.Context, env *action.Environment) error {
+	transferReq := models.NewRequestTransferServiceAccountAdministrationRequest()
+	transferReq.SetServiceAccount(a.serviceAccount)
+	transferReq.SetGroup(a.group)
+	transferReq.SetComment(action.Ptr("automation serviceAccountAdministeredByGroup"))
+	err := submitAndAccept(ctx, transferReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to transfer service account administration in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *serviceAccountAdministeredByGroup) Observe(model *action.Model) {
+	admin := a.serviceAccount.GetTechnicalAdministrator()
+	model.ObserveGroup(a.group)
+	model.SetName(*a.serviceAccount.GetUuid(), *a.serviceAccount.GetUsername())
+	model.SetName(*admin.GetUuid(), *admin.GetName())
+	model.SetServiceAccountAdmin(*a.serviceAccount.GetUuid(), *admin.GetUuid())
+}
+
+func (a *serviceAccountAdministeredByGroup) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, model.ServiceAccountAdmin(a.serviceAccountUUID), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, a.groupUUID, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetServiceAccountAdmin(a.serviceAccountUUID, a.groupUUID)
+	return nil
+}
+
+func (a *serviceAccountAdministeredByGroup) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.serviceAccount.GetTechnicalAdministrator().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+		NewAccountInGroup(*env.Account2.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*serviceAccountAdministeredByGroup) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *serviceAccountAdministeredByGroup) Revert() action.AutomationAction {
+	return NewServiceAccountAdministeredByGroup(a.serviceAccountUUID, *a.serviceAccount.GetTechnicalAdministrator().GetUuid())
+}
+
+func (a *serviceAccountAdministeredByGroup) Progress() string {
+	serviceAccountName := a.serviceAccountUUID
+	if a.serviceAccount != nil {
+		serviceAccountName = *a.serviceAccount.GetUsername()
+	}
+	return fmt.Sprintf("Transfering %s", serviceAccountName)
+}
+
+func (a *serviceAccountAdministeredByGroup) String() string {
+	serviceAccountName := a.serviceAccountUUID
+	if a.serviceAccount != nil {
+		serviceAccountName = *a.serviceAccount.GetUsername()
+	}
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Transfer administration of service account '%s' to '%s'", serviceAccountName, groupName)
+}
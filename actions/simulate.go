@@ -0,0 +1,26 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+)
+
+// uuidOf returns the uuid of the authenticated account, or the placeholder for account 3 when it
+// is not authenticated yet.
+func uuidOf(account *action.AuthenticatedAccount) string {
+	if account == nil {
+		return action.Account3UUIDPlaceholder
+	}
+	return *account.Account.GetUuid()
+}
+
+func requireManager(model *action.Model, groupUUID string, accountUUID string) error {
+	if !model.IsManager(groupUUID, accountUUID) {
+		return fmt.Errorf("%s is not a manager of %s", model.Name(accountUUID), model.Name(groupUUID))
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+const DesiredStateVersion = 1
+
+// DesiredState describes the target state of a migration. It can be read from a JSON or YAML
+// file and is translated into the actions in this package.
+type DesiredState struct {
+	Version                       int                              `json:"version" yaml:"version"`
+	Description                   string                           `json:"description" yaml:"description"`
+	Groups                        []GroupState                     `json:"groups" yaml:"groups"`
+	GroupClassifications          []GroupClassificationState       `json:"groupClassifications" yaml:"groupClassifications"`
+	GroupMemberships              []GroupMembershipState           `json:"groupMemberships" yaml:"groupMemberships"`
+	GroupAuthorizations           []GroupAuthorizationState        `json:"groupAuthorizations" yaml:"groupAuthorizations"`
+	GroupsOnSystem                []GroupOnSystemState             `json:"groupsOnSystem" yaml:"groupsOnSystem"`
+	GroupOnSystemOwners           []GroupOnSystemOwnerState        `json:"groupOnSystemOwners" yaml:"groupOnSystemOwners"`
+	GroupOnSystemProvisioning     []GroupOnSystemProvisioningState `json:"groupOnSystemProvisioning" yaml:"groupOnSystemProvisioning"`
+	SystemOwners                  []SystemOwnerState               `json:"systemOwners" yaml:"systemOwners"`
+	ClientOwners                  []ClientOwnerState               `json:"clientOwners" yaml:"clientOwners"`
+	ClientPermissions             []ClientPermissionState          `json:"clientPermissions" yaml:"clientPermissions"`
+	ServiceAccountAdmins          []ServiceAccountAdminState       `json:"serviceAccountAdmins" yaml:"serviceAccountAdmins"`
+	VaultRecords                  []VaultRecordState               `json:"vaultRecords" yaml:"vaultRecords"`
+	OrganizationalUnitMemberships []OrganizationalUnitMemberState  `json:"organizationalUnitMemberships" yaml:"organizationalUnitMemberships"`
+	// ArchiveOrganizationalUnit is the organizational unit archived groups are moved to.
+	ArchiveOrganizationalUnit string `json:"archiveOrganizationalUnit" yaml:"archiveOrganizationalUnit"`
+}
+
+// GroupState ensures a group with the given name exists, created with the manager and optionally
+// the classification and organizational unit, or ensures it does not exist when absent is set.
+// When renamedFrom is set, the group with that name is renamed instead. When archived is set, the
+// existing group is moved to the archive organizational unit of the desired state. Elsewhere in
+// the desired state, a group can be referred to by name as 'name:<name>' instead of by UUID, which
+// is required for groups that are created by the desired state.
+type GroupState struct {
+	Name               string `json:"name" yaml:"name"`
+	Manager            string `json:"manager" yaml:"manager"`
+	Classification     string `json:"classification" yaml:"classification"`
+	OrganizationalUnit string `json:"organizationalUnit" yaml:"organizationalUnit"`
+	RenamedFrom        string `json:"renamedFrom" yaml:"renamedFrom"`
+	Archived           bool   `json:"archived" yaml:"archived"`
+	Absent             bool   `json:"absent" yaml:"absent"`
+}
+
+type GroupClassificationState struct {
+	Group          string `json:"group" yaml:"group"`
+	Classification string `json:"classification" yaml:"classification"`
+}
+
+// GroupMembershipState ensures an account is a member of a group. Rights can be 'manager',
+// 'member', 'absent' to remove the account from the group, or empty to accept any membership.
+type GroupMembershipState struct {
+	Account string `json:"account" yaml:"account"`
+	Group   string `json:"group" yaml:"group"`
+	Rights  string `json:"rights" yaml:"rights"`
+}
+
+// GroupAuthorizationState ensures the authorization of the given type (auditing, delegation,
+// membership or provisioning) on a group is handled by the authorizing group. When no
+// authorizing group is given, the authorization is removed.
+type GroupAuthorizationState struct {
+	Group            string `json:"group" yaml:"group"`
+	AuthorizingGroup string `json:"authorizingGroup" yaml:"authorizingGroup"`
+	Type             string `json:"type" yaml:"type"`
+}
+
+// GroupOnSystemState ensures a group on system with the given name exists on the system, created
+// with the type, display name and owner, or ensures it does not exist when absent is set.
+type GroupOnSystemState struct {
+	System       string `json:"system" yaml:"system"`
+	NameInSystem string `json:"nameInSystem" yaml:"nameInSystem"`
+	Type         string `json:"type" yaml:"type"`
+	DisplayName  string `json:"displayName" yaml:"displayName"`
+	Owner        string `json:"owner" yaml:"owner"`
+	Absent       bool   `json:"absent" yaml:"absent"`
+}
+
+type GroupOnSystemOwnerState struct {
+	System       string `json:"system" yaml:"system"`
+	NameInSystem string `json:"nameInSystem" yaml:"nameInSystem"`
+	Owner        string `json:"owner" yaml:"owner"`
+}
+
+// GroupOnSystemProvisioningState ensures the group is provisioned to the group on system, or is
+// not provisioned to it when absent is set.
+type GroupOnSystemProvisioningState struct {
+	System       string `json:"system" yaml:"system"`
+	NameInSystem string `json:"nameInSystem" yaml:"nameInSystem"`
+	Group        string `json:"group" yaml:"group"`
+	Absent       bool   `json:"absent" yaml:"absent"`
+}
+
+type SystemOwnerState struct {
+	System string `json:"system" yaml:"system"`
+	Owner  string `json:"owner" yaml:"owner"`
+}
+
+type ClientOwnerState struct {
+	Client string `json:"client" yaml:"client"`
+	Owner  string `json:"owner" yaml:"owner"`
+}
+
+// ClientPermissionState ensures the client holds the permission on either a group or a system, or
+// does not hold it when absent is set.
+type ClientPermissionState struct {
+	Client     string `json:"client" yaml:"client"`
+	Permission string `json:"permission" yaml:"permission"`
+	Group      string `json:"group" yaml:"group"`
+	System     string `json:"system" yaml:"system"`
+	Absent     bool   `json:"absent" yaml:"absent"`
+}
+
+// ServiceAccountAdminState ensures the service account is administered by the admin group.
+type ServiceAccountAdminState struct {
+	ServiceAccount string `json:"serviceAccount" yaml:"serviceAccount"`
+	Admin          string `json:"admin" yaml:"admin"`
+}
+
+// VaultRecordState ensures a record of the source group vault, identified by UUID or name, is in
+// the vault of the target group. Mode is 'move' (the default) or 'copy'.
+type VaultRecordState struct {
+	SourceGroup string `json:"sourceGroup" yaml:"sourceGroup"`
+	Record      string `json:"record" yaml:"record"`
+	TargetGroup string `json:"targetGroup" yaml:"targetGroup"`
+	Mode        string `json:"mode" yaml:"mode"`
+}
+
+// OrganizationalUnitMemberState ensures an account is in an organizational unit, or is not in it
+// when absent is set.
+type OrganizationalUnitMemberState struct {
+	Account            string `json:"account" yaml:"account"`
+	OrganizationalUnit string `json:"organizationalUnit" yaml:"organizationalUnit"`
+	Absent             bool   `json:"absent" yaml:"absent"`
+}
+
+func ReadDesiredStateFile(path string) (*DesiredState, error) {
+	state := &DesiredState{}
+	err := action.UnmarshalFile(path, state)
+	if err != nil {
+		return nil, err
+	}
+	if state.Version != DesiredStateVersion {
+		return nil, fmt.Errorf("unsupported desired state version %d in %s, expected %d", state.Version, path, DesiredStateVersion)
+	}
+	return state, nil
+}
+
+func required(kind string, index int, field string, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s %d: %s is required", kind, index+1, field)
+	}
+	return nil
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// Actions translates the desired state into actions, in the order in which they appear in the
+// state. Groups are created and renamed before all other actions, and archived and removed after
+// them, so the other actions can refer to new groups by name and can clean up groups that are going
+// to be archived or removed.
+func (s *DesiredState) Actions() ([]action.AutomationAction, error) {
+	ret := make([]action.AutomationAction, 0)
+	removals := make([]action.AutomationAction, 0)
+	for i, g := range s.Groups {
+		if err := required("group", i, "name", g.Name); err != nil {
+			return nil, err
+		}
+		if g.Absent {
+			removals = append(removals, NewGroupNotExists(g.Name))
+			continue
+		}
+		if g.Archived {
+			if s.ArchiveOrganizationalUnit == "" {
+				return nil, fmt.Errorf("group %d: archived requires archiveOrganizationalUnit to be set", i+1)
+			}
+			removals = append(removals, NewGroupInOrganizationalUnit(GroupByName(g.Name), s.ArchiveOrganizationalUnit))
+			continue
+		}
+		if g.RenamedFrom != "" {
+			ret = append(ret, NewGroupRenamed(GroupByName(g.RenamedFrom), g.Name))
+			continue
+		}
+		if err := required("group", i, "manager", g.Manager); err != nil {
+			return nil, err
+		}
+		ret = append(ret, NewGroupExists(g.Name, g.Manager, optional(g.Classification), optional(g.OrganizationalUnit)))
+	}
+	for i, c := range s.GroupClassifications {
+		if err := required("group classification", i, "group", c.Group); err != nil {
+			return nil, err
+		}
+		if err := required("group classification", i, "classification", c.Classification); err != nil {
+			return nil, err
+		}
+		ret = append(ret, NewGroupClassification(c.Group, c.Classification))
+	}
+	for i, m := range s.GroupMemberships {
+		if err := required("group membership", i, "account", m.Account); err != nil {
+			return nil, err
+		}
+		if err := required("group membership", i, "group", m.Group); err != nil {
+			return nil, err
+		}
+		if m.Rights == "absent" {
+			ret = append(ret, NewAccountNotInGroup(m.Account, m.Group))
+			continue
+		}
+		var rights *string
+		if m.Rights != "" {
+			rights = &m.Rights
+		}
+		parsedRights, err := parseRights(rights)
+		if err != nil {
+			return nil, fmt.Errorf("group membership %d: %s", i+1, err)
+		}
+		ret = append(ret, NewAccountInGroup(m.Account, m.Group, parsedRights))
+	}
+	for i, a := range s.GroupAuthorizations {
+		if err := required("group authorization", i, "group", a.Group); err != nil {
+			return nil, err
+		}
+		authType, err := parseAuthorizationDescription(a.Type)
+		if err != nil {
+			return nil, fmt.Errorf("group authorization %d: %s", i+1, err)
+		}
+		if a.AuthorizingGroup == "" {
+			ret = append(ret, NewDisconnectGroupAuthorization(a.Group, authType))
+		} else {
+			ret = append(ret, NewConnectGroupAuthorization(a.Group, a.AuthorizingGroup, authType))
+		}
+	}
+	for i, g := range s.GroupsOnSystem {
+		if err := required("group on system", i, "system", g.System); err != nil {
+			return nil, err
+		}
+		if err := required("group on system", i, "nameInSystem", g.NameInSystem); err != nil {
+			return nil, err
+		}
+		if g.Absent {
+			ret = append(ret, NewGroupOnSystemNotExists(g.System, g.NameInSystem))
+			continue
+		}
+		if err := required("group on system", i, "type", g.Type); err != nil {
+			return nil, err
+		}
+		if err := required("group on system", i, "owner", g.Owner); err != nil {
+			return nil, err
+		}
+		gosType, err := parseGroupOnSystemType(g.Type)
+		if err != nil {
+			return nil, fmt.Errorf("group on system %d: %s", i+1, err)
+		}
+		displayName := g.DisplayName
+		if displayName == "" {
+			displayName = g.NameInSystem
+		}
+		ret = append(ret, NewGroupOnSystemExists(g.System, g.NameInSystem, gosType, displayName, g.Owner))
+	}
+	for i, o := range s.GroupOnSystemOwners {
+		if err := required("group on system owner", i, "system", o.System); err != nil {
+			return nil, err
+		}
+		if err := required("group on system owner", i, "nameInSystem", o.NameInSystem); err != nil {
+			return nil, err
+		}
+		if err := required("group on system owner", i, "owner", o.Owner); err != nil {
+			return nil, err
+		}
+		ret = append(ret, NewGroupOwnerOfGOS(o.System, o.NameInSystem, o.Owner))
+	}
+	for i, p := range s.GroupOnSystemProvisioning {
+		if err := required("group on system provisioning", i, "system", p.System); err != nil {
+			return nil, err
+		}
+		if err := required("group on system provisioning", i, "nameInSystem", p.NameInSystem); err != nil {
+			return nil, err
+		}
+		if err := required("group on system provisioning", i, "group", p.Group); err != nil {
+			return nil, err
+		}
+		if p.Absent {
+			ret = append(ret, NewGroupNotProvisionedToGOS(p.System, p.NameInSystem, p.Group))
+		} else {
+			ret = append(ret, NewGroupProvisionedToGOS(p.System, p.NameInSystem, p.Group, s.groupOnSystemOwner(p.System, p.NameInSystem)))
+		}
+	}
+	for i, o := range s.SystemOwners {
+		if err := required("system owner", i, "system", o.System); err != nil {
+			return nil, err
+		}
+		if err := required("system owner", i, "owner", o.Owner); err != nil {
+			return nil, err
+		}
+		ret = append(ret, NewSystemOwnedByGroup(o.System, o.Owner))
+	}
+	for i, o := range s.ClientOwners {
+		if err := required("client owner", i, "client", o.Client); err != nil {
+			return nil, err
+		}
+		if err := required("client owner", i, "owner", o.Owner); err != nil {
+			return nil, err
+		}
+		ret = append(ret, NewClientOwnedByGroup(o.Client, o.Owner))
+	}
+	for i, p := range s.ClientPermissions {
+		if err := required("client permission", i, "client", p.Client); err != nil {
+			return nil, err
+		}
+		if err := required("client permission", i, "permission", p.Permission); err != nil {
+			return nil, err
+		}
+		group, system := optional(p.Group), optional(p.System)
+		if err := checkPermissionTarget(group, system); err != nil {
+			return nil, fmt.Errorf("client permission %d: %s", i+1, err)
+		}
+		permissionType, err := parseClientPermissionType(p.Permission)
+		if err != nil {
+			return nil, fmt.Errorf("client permission %d: %s", i+1, err)
+		}
+		if p.Absent {
+			ret = append(ret, NewClientPermissionNotGranted(p.Client, permissionType, group, system))
+		} else {
+			ret = append(ret, NewClientPermissionGranted(p.Client, permissionType, group, system))
+		}
+	}
+	for i, a := range s.ServiceAccountAdmins {
+		if err := required("service account admin", i, "serviceAccount", a.ServiceAccount); err != nil {
+			return nil, err
+		}
+		if err := required("service account admin", i, "admin", a.Admin); err != nil {
+			return nil, err
+		}
+		ret = append(ret, NewServiceAccountAdministeredByGroup(a.ServiceAccount, a.Admin))
+	}
+	for i, v := range s.VaultRecords {
+		if err := required("vault record", i, "sourceGroup", v.SourceGroup); err != nil {
+			return nil, err
+		}
+		if err := required("vault record", i, "record", v.Record); err != nil {
+			return nil, err
+		}
+		if err := required("vault record", i, "targetGroup", v.TargetGroup); err != nil {
+			return nil, err
+		}
+		mode := models.MOVE_VAULTMOVEVAULTRECORDACTION
+		if v.Mode != "" {
+			var err error
+			mode, err = parseVaultRecordMode(v.Mode)
+			if err != nil {
+				return nil, fmt.Errorf("vault record %d: %s", i+1, err)
+			}
+		}
+		ret = append(ret, NewVaultRecordInGroup(v.SourceGroup, v.Record, v.TargetGroup, mode))
+	}
+	for i, m := range s.OrganizationalUnitMemberships {
+		if err := required("organizational unit membership", i, "account", m.Account); err != nil {
+			return nil, err
+		}
+		if err := required("organizational unit membership", i, "organizationalUnit", m.OrganizationalUnit); err != nil {
+			return nil, err
+		}
+		if m.Absent {
+			ret = append(ret, NewAccountNotInOU(m.Account, m.OrganizationalUnit))
+		} else {
+			ret = append(ret, NewAccountInOU(m.Account, m.OrganizationalUnit))
+		}
+	}
+	return append(ret, removals...), nil
+}
+
+// groupOnSystemOwner returns the owner of the group on system when the state creates it, so its
+// provisioning can be set up before it exists.
+func (s *DesiredState) groupOnSystemOwner(system string, nameInSystem string) *string {
+	for _, g := range s.GroupsOnSystem {
+		if g.System == system && g.NameInSystem == nameInSystem && !g.Absent {
+			return optional(g.Owner)
+		}
+	}
+	return nil
+}
+
+// Root returns a single action that performs all actions of the desired state, to be passed to
+// action.Run.
+func (s *DesiredState) Root() (action.AutomationAction, error) {
+	children, err := s.Actions()
+	if err != nil {
+		return nil, err
+	}
+	description := s.Description
+	if description == "" {
+		description = "Apply desired state"
+	}
+	return action.NewSequence(description, children...), nil
+}
+
+func parseAuthorizationDescription(value string) (models.RequestAuthorizingGroupType, error) {
+	for _, t := range []models.RequestAuthorizingGroupType{
+		models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE,
+		models.DELEGATION_REQUESTAUTHORIZINGGROUPTYPE,
+		models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE,
+		models.PROVISIONING_REQUESTAUTHORIZINGGROUPTYPE,
+	} {
+		if describe(t) == value {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid authorization type '%s', expected auditing, delegation, membership or provisioning", value)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+type systemOwnedByGroup struct {
+	systemUUID string
+	groupUUID  string
+	system     models.ProvisioningProvisionedSystemable
+	group      models.GroupGroupable
+}
+
+func NewSystemOwnedByGroup(systemUUID string, groupUUID string) action.AutomationAction {
+	return &systemOwnedByGroup{
+		systemUUID: systemUUID,
+		groupUUID:  groupUUID,
+	}
+}
+
+func init() {
+	action.Register("systemOwnedByGroup", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 2)
+		if err != nil {
+			return nil, err
+		}
+		return NewSystemOwnedByGroup(*parameters[0], *parameters[1]), nil
+	})
+}
+
+func (a *systemOwnedByGroup) TypeID() string {
+	return "systemOwnedByGroup"
+}
+
+func (a *systemOwnedByGroup) Parameters() []*string {
+	return []*string{&a.systemUUID, &a.groupUUID}
+}
+
+func (a *systemOwnedByGroup) References() []action.Reference {
+	return []action.Reference{{Kind: systemKind, UUID: a.systemUUID}, {Kind: groupKind, UUID: a.groupUUID}}
+}
+
+func (a *systemOwnedByGroup) Touches() []string {
+	if a.system == nil {
+		return nil
+	}
+	return []string{a.systemUUID, a.groupUUID, *a.system.GetOwner().GetUuid()}
+}
+
+func (a *systemOwnedByGroup) Init(ctx context.Context, env *action.Environment) error {
+	system, err := readSystem(ctx, env, a.systemUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read system with uuid %s: %s", a.systemUUID, action.KeyHubError(err))
+	}
+	a.system = system
+
+	group, err := readGroup(ctx, env, a.groupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.groupUUID, action.KeyHubError(err))
+	}
+	a.group = group
+	a.groupUUID = *group.GetUuid()
+	return nil
+}
+
+func (a *systemOwnedByGroup) IsSatisfied() bool {
+	return *a.system.GetOwner().GetUuid() == a.groupUUID
+}
+
+func (a *systemOwnedByGroup) Requires3() bool {
+	return false
+}
+
+func (a *systemOwnedByGroup) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *systemOwnedByGroup) Execute(ctx context.Context, env *action.Environment) error {
+	transferReq := models.NewRequestTransferProvisionedSystemOwnershipRequest()
+	transferReq.SetSystem(a.system)
+	transferReq.SetGroup(a.group)
+	transferReq.SetComment(action.Ptr("automation systemOwnedByGroup"))
+	err := submitAndAccept(ctx, transferReq, env.Account1, env.Account2)
+	if err != nil {
+		return fmt.Errorf("cannot request to transfer system ownership in '%s': %s", a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+func (a *systemOwnedByGroup) Observe(model *action.Model) {
+	model.ObserveGroup(a.group)
+	model.SetName(*a.system.GetUuid(), *a.system.GetName())
+	model.SetName(*a.system.GetOwner().GetUuid(), *a.system.GetOwner().GetName())
+	model.SetSystemOwner(*a.system.GetUuid(), *a.system.GetOwner().GetUuid())
+}
+
+func (a *systemOwnedByGroup) Simulate(env *action.Environment, model *action.Model) error {
+	err := requireManager(model, model.SystemOwner(a.systemUUID), uuidOf(env.Account1))
+	if err != nil {
+		return err
+	}
+	err = requireManager(model, a.groupUUID, uuidOf(env.Account2))
+	if err != nil {
+		return err
+	}
+	model.SetSystemOwner(a.systemUUID, a.groupUUID)
+	return nil
+}
+
+func (a *systemOwnedByGroup) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), *a.system.GetOwner().GetUuid(), action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+		NewAccountInGroup(*env.Account2.Account.GetUuid(), a.groupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*systemOwnedByGroup) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+func (a *systemOwnedByGroup) Revert() action.AutomationAction {
+	return NewSystemOwnedByGroup(a.systemUUID, *a.system.GetOwner().GetUuid())
+}
+
+func (a *systemOwnedByGroup) Progress() string {
+	systemName := a.systemUUID
+	if a.system != nil {
+		systemName = *a.system.GetName()
+	}
+	return fmt.Sprintf("Transfering %s", systemName)
+}
+
+func (a *systemOwnedByGroup) String() string {
+	systemName := a.systemUUID
+	if a.system != nil {
+		systemName = *a.system.GetName()
+	}
+	groupName := a.groupUUID
+	if a.group != nil {
+		groupName = *a.group.GetName()
+	}
+	return fmt.Sprintf("Transfer ownership of system '%s' to '%s'", systemName, groupName)
+}
@@ -0,0 +1,270 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhubgroup "github.com/topicuskeyhub/sdk-go/group"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+// vaultRecordInGroup ensures a vault record of the source group, identified by UUID or by name,
+// exists in the vault of the target group. With 'move' the record is removed from the source
+// vault, with 'copy' it is kept.
+type vaultRecordInGroup struct {
+	sourceGroupUUID string
+	record          string
+	targetGroupUUID string
+	mode            models.VaultMoveVaultRecordAction
+	sourceGroup     models.GroupGroupable
+	targetGroup     models.GroupGroupable
+	sourceRecord    models.VaultVaultRecordable
+	targetRecord    models.VaultVaultRecordable
+	accessible      bool
+}
+
+func NewVaultRecordInGroup(sourceGroupUUID string, record string, targetGroupUUID string, mode models.VaultMoveVaultRecordAction) action.AutomationAction {
+	return &vaultRecordInGroup{
+		sourceGroupUUID: sourceGroupUUID,
+		record:          record,
+		targetGroupUUID: targetGroupUUID,
+		mode:            mode,
+	}
+}
+
+func init() {
+	action.Register("vaultRecordInGroup", func(parameters []*string) (action.AutomationAction, error) {
+		err := action.CheckParameters(parameters, 4)
+		if err != nil {
+			return nil, err
+		}
+		mode, err := parseVaultRecordMode(*parameters[3])
+		if err != nil {
+			return nil, err
+		}
+		return NewVaultRecordInGroup(*parameters[0], *parameters[1], *parameters[2], mode), nil
+	})
+}
+
+func (a *vaultRecordInGroup) TypeID() string {
+	return "vaultRecordInGroup"
+}
+
+func (a *vaultRecordInGroup) Parameters() []*string {
+	return []*string{&a.sourceGroupUUID, &a.record, &a.targetGroupUUID, action.Ptr(describeVaultRecordMode(a.mode))}
+}
+
+func (a *vaultRecordInGroup) References() []action.Reference {
+	return []action.Reference{{Kind: groupKind, UUID: a.sourceGroupUUID}, {Kind: groupKind, UUID: a.targetGroupUUID}}
+}
+
+func (a *vaultRecordInGroup) Touches() []string {
+	return []string{a.sourceGroupUUID, a.targetGroupUUID}
+}
+
+func (a *vaultRecordInGroup) Init(ctx context.Context, env *action.Environment) error {
+	sourceGroup, err := readGroup(ctx, env, a.sourceGroupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.sourceGroupUUID, action.KeyHubError(err))
+	}
+	a.sourceGroup = sourceGroup
+	a.sourceGroupUUID = *sourceGroup.GetUuid()
+
+	targetGroup, err := readGroup(ctx, env, a.targetGroupUUID)
+	if err != nil {
+		return fmt.Errorf("unable to read group with uuid %s: %s", a.targetGroupUUID, action.KeyHubError(err))
+	}
+	a.targetGroup = targetGroup
+	a.targetGroupUUID = *targetGroup.GetUuid()
+
+	// The vaults can only be read once both groups exist and Account1 has access to them, which is
+	// arranged in Setup. Until then, the record is assumed to still be in the source vault.
+	a.accessible = false
+	for _, group := range []models.GroupGroupable{sourceGroup, targetGroup} {
+		if _, ok := groupName(*group.GetUuid()); ok {
+			return nil
+		}
+		access, err := hasVaultAccess(ctx, env, env.Account1.Account, group)
+		if err != nil {
+			return fmt.Errorf("unable to read vault access of %s: %s", *group.GetName(), action.KeyHubError(err))
+		}
+		if !access {
+			return nil
+		}
+	}
+	return a.load(ctx, env)
+}
+
+// load reads the record from the source and target vaults. In the target vault, the record is
+// looked up by the identifier and by the name of the source record, as a copy gets a new UUID.
+func (a *vaultRecordInGroup) load(ctx context.Context, env *action.Environment) error {
+	sourceRecord, err := findVaultRecord(ctx, env, a.sourceGroup, a.record)
+	if err != nil {
+		return fmt.Errorf("unable to read vault of %s: %s", *a.sourceGroup.GetName(), err)
+	}
+	identifiers := []string{a.record}
+	if sourceRecord != nil {
+		identifiers = append(identifiers, *sourceRecord.GetName())
+	}
+	targetRecord, err := findVaultRecord(ctx, env, a.targetGroup, identifiers...)
+	if err != nil {
+		return fmt.Errorf("unable to read vault of %s: %s", *a.targetGroup.GetName(), err)
+	}
+	if sourceRecord == nil && targetRecord == nil {
+		return fmt.Errorf("vault record %s not found in %s or %s", a.record, *a.sourceGroup.GetName(), *a.targetGroup.GetName())
+	}
+	a.sourceRecord = sourceRecord
+	a.targetRecord = targetRecord
+	a.accessible = true
+	return nil
+}
+
+// findVaultRecord returns the first record in the vault of the group whose UUID or name matches
+// one of the identifiers, or nil when there is none.
+func findVaultRecord(ctx context.Context, env *action.Environment, group models.GroupGroupable, identifiers ...string) (models.VaultVaultRecordable, error) {
+	groupID, err := action.SelfID(group)
+	if err != nil {
+		return nil, err
+	}
+	for _, identifier := range identifiers {
+		for _, query := range []keyhubgroup.ItemVaultRecordRequestBuilderGetQueryParameters{{Uuid: []string{identifier}}, {Name: []string{identifier}}} {
+			records, err := env.Account1.Client.Group().ByGroupidInt64(groupID).Vault().Record().Get(ctx, &keyhubgroup.ItemVaultRecordRequestBuilderGetRequestConfiguration{
+				QueryParameters: &query,
+			})
+			if err != nil {
+				return nil, action.KeyHubError(err)
+			}
+			if len(records.GetItems()) > 0 {
+				return records.GetItems()[0], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (a *vaultRecordInGroup) recordName() string {
+	if a.sourceRecord != nil {
+		return *a.sourceRecord.GetName()
+	}
+	if a.targetRecord != nil {
+		return *a.targetRecord.GetName()
+	}
+	return a.record
+}
+
+func (a *vaultRecordInGroup) IsSatisfied() bool {
+	if !a.accessible || a.targetRecord == nil {
+		return false
+	}
+	return a.mode == models.COPY_VAULTMOVEVAULTRECORDACTION || a.sourceRecord == nil
+}
+
+func (a *vaultRecordInGroup) Requires3() bool {
+	return false
+}
+
+func (a *vaultRecordInGroup) AllowGlobalOptimization() bool {
+	return false
+}
+
+func (a *vaultRecordInGroup) Execute(ctx context.Context, env *action.Environment) error {
+	if !a.accessible {
+		err := a.load(ctx, env)
+		if err != nil {
+			return fmt.Errorf("cannot read vault records in '%s': %s", a.String(), err)
+		}
+		if a.IsSatisfied() {
+			return nil
+		}
+	}
+	if a.sourceRecord == nil {
+		return fmt.Errorf("vault record not found in source vault in '%s'", a.String())
+	}
+	groupID, err := action.SelfID(a.sourceGroup)
+	if err != nil {
+		return fmt.Errorf("invalid group in '%s': %s", a.String(), err)
+	}
+	recordID, err := action.SelfID(a.sourceRecord)
+	if err != nil {
+		return fmt.Errorf("invalid vault record in '%s': %s", a.String(), err)
+	}
+	move := models.NewVaultMoveVaultRecord()
+	move.SetAction(&a.mode)
+	move.SetGroup(a.targetGroup)
+	err = env.Account1.Client.Group().ByGroupidInt64(groupID).Vault().Record().ByRecordidInt64(recordID).Move().Post(ctx, move, nil)
+	if err != nil {
+		return fmt.Errorf("cannot %s vault record in '%s': %s", describeVaultRecordMode(a.mode), a.String(), action.KeyHubError(err))
+	}
+	return nil
+}
+
+// Observe records the records found in the vaults. When the vaults cannot be read yet, the record
+// is assumed to be in the source vault only.
+func (a *vaultRecordInGroup) Observe(model *action.Model) {
+	model.ObserveGroup(a.sourceGroup)
+	model.ObserveGroup(a.targetGroup)
+	if !a.accessible {
+		model.SetVaultRecord(a.sourceGroupUUID, a.record, true)
+		return
+	}
+	model.SetVaultRecord(a.sourceGroupUUID, a.recordName(), a.sourceRecord != nil)
+	model.SetVaultRecord(a.targetGroupUUID, a.recordName(), a.targetRecord != nil)
+}
+
+func (a *vaultRecordInGroup) Simulate(env *action.Environment, model *action.Model) error {
+	for _, groupUUID := range []string{a.sourceGroupUUID, a.targetGroupUUID} {
+		err := requireManager(model, groupUUID, uuidOf(env.Account1))
+		if err != nil {
+			return err
+		}
+	}
+	if !model.HasVaultRecord(a.sourceGroupUUID, a.recordName()) {
+		return fmt.Errorf("vault record %s is not in the vault of %s", a.recordName(), model.Name(a.sourceGroupUUID))
+	}
+	model.SetVaultRecord(a.targetGroupUUID, a.recordName(), true)
+	if a.mode == models.MOVE_VAULTMOVEVAULTRECORDACTION {
+		model.SetVaultRecord(a.sourceGroupUUID, a.recordName(), false)
+	}
+	return nil
+}
+
+// Setup makes Account1 a manager of both groups, which gives it access to both vaults using the
+// vault recovery key.
+func (a *vaultRecordInGroup) Setup(env *action.Environment) []action.AutomationAction {
+	return []action.AutomationAction{
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.sourceGroupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+		NewAccountInGroup(*env.Account1.Account.GetUuid(), a.targetGroupUUID, action.Ptr(models.MANAGER_GROUPGROUPRIGHTS)),
+	}
+}
+
+func (*vaultRecordInGroup) Perform(env *action.Environment) []action.AutomationAction {
+	return make([]action.AutomationAction, 0)
+}
+
+// Revert moves a moved record back to the source vault. A copy is not reverted.
+func (a *vaultRecordInGroup) Revert() action.AutomationAction {
+	if a.mode != models.MOVE_VAULTMOVEVAULTRECORDACTION {
+		return nil
+	}
+	return NewVaultRecordInGroup(a.targetGroupUUID, a.recordName(), a.sourceGroupUUID, models.MOVE_VAULTMOVEVAULTRECORDACTION)
+}
+
+func (a *vaultRecordInGroup) Progress() string {
+	return fmt.Sprintf("%s %s", firstCharToUpper(describeVaultRecordMode(a.mode)), a.recordName())
+}
+
+func (a *vaultRecordInGroup) String() string {
+	sourceGroupName := a.sourceGroupUUID
+	if a.sourceGroup != nil {
+		sourceGroupName = *a.sourceGroup.GetName()
+	}
+	targetGroupName := a.targetGroupUUID
+	if a.targetGroup != nil {
+		targetGroupName = *a.targetGroup.GetName()
+	}
+	return fmt.Sprintf("%s vault record '%s' from '%s' to '%s'", firstCharToUpper(describeVaultRecordMode(a.mode)), a.recordName(), sourceGroupName, targetGroupName)
+}
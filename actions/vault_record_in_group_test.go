@@ -0,0 +1,73 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package actions_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func TestVaultRecordInGroup(t *testing.T) {
+	runActionTests(t, []actionTest{
+		{
+			name: "move",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				source := f.AddGroup("Source")
+				target := f.AddGroup("Target")
+				f.AddGroupVaultRecord(source, "db-password", "secret")
+				return actions.NewVaultRecordInGroup(source.UUID, "db-password", target.UUID, models.MOVE_VAULTMOVEVAULTRECORDACTION), func(t *testing.T) {
+					if slices.Contains(f.VaultRecords(source), "db-password") {
+						t.Errorf("db-password is still in the vault of Source")
+					}
+					if !slices.Contains(f.VaultRecords(target), "db-password") {
+						t.Errorf("db-password is not in the vault of Target")
+					}
+					f.checkRights(t, source, f.admin1, nil)
+					f.checkRights(t, target, f.admin1, nil)
+				}
+			},
+			plan: []string{
+				"Add admin1 to 'Source' as manager",
+				"Add admin1 to 'Target' as manager",
+				"Move vault record 'db-password' from 'Source' to 'Target'",
+				"Remove admin1 from 'Target'",
+				"Remove admin1 from 'Source'",
+			},
+		},
+		{
+			// The target group does not exist yet when the plan is collected.
+			name: "move to new group",
+			setup: func(f *fixture) (action.AutomationAction, func(t *testing.T)) {
+				source := f.AddGroup("Source")
+				f.AddGroupVaultRecord(source, "db-password", "secret")
+				owner := f.AddAccount("owner")
+				return action.NewSequence("Move to Target",
+						actions.NewGroupExists("Target", owner.UUID, nil, nil),
+						actions.NewVaultRecordInGroup(source.UUID, "db-password", actions.GroupByName("Target"), models.MOVE_VAULTMOVEVAULTRECORDACTION),
+					), func(t *testing.T) {
+						target := f.GroupByName("Target")
+						if target == nil {
+							t.Fatalf("Target was not created")
+						}
+						if !slices.Contains(f.VaultRecords(target), "db-password") {
+							t.Errorf("db-password is not in the vault of Target")
+						}
+						f.checkRights(t, target, f.admin1, nil)
+					}
+			},
+			plan: []string{
+				"Create group 'Target' managed by owner",
+				"Add admin1 to 'Source' as manager",
+				"Add admin1 to 'Target' as manager",
+				"Move vault record 'db-password' from 'Source' to 'Target'",
+				"Remove admin1 from 'Target'",
+				"Remove admin1 from 'Source'",
+			},
+		},
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+// Command keyhub-automation applies a desired-state file or executes a previously exported plan
+// against Topicus KeyHub.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/automation-framework/actions"
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] <desired-state.yaml>\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "       %s [options] -plan <plan.yaml>\n\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
+
+func main() {
+	issuer := flag.String("issuer", os.Getenv("KEYHUB_ISSUER"), "the issuer of Topicus KeyHub, for example https://keyhub.example.com")
+	clientID := flag.String("client-id", os.Getenv("KEYHUB_CLIENT_ID"), "the client ID of the OAuth2 application used to log in")
+	clientSecret := flag.String("client-secret", os.Getenv("KEYHUB_CLIENT_SECRET"), "the client secret of the OAuth2 application used to log in")
+	refreshTokens := flag.String("refresh-tokens", "", "comma separated files holding the refresh tokens of account 1, 2 and 3, instead of logging in with the device flow")
+	tokenCache := flag.String("token-cache", "", "cache the tokens of logged in accounts in this file, encrypted with the passphrase in KEYHUB_TOKEN_CACHE_PASSPHRASE")
+	vaultRecoveryRecord := flag.String("vault-recovery-record", "", "the UUID of the vault record holding the vault recovery key")
+	exportPlan := flag.String("export-plan", "", "write the collected actions to this plan file instead of executing them")
+	planFile := flag.String("plan", "", "execute the plan in this file instead of a desired state")
+	yes := flag.Bool("yes", false, "execute the actions without asking for confirmation")
+	onFailure := flag.String("on-failure", "ask", "what to do when an action fails: ask, abort, skip or retry")
+	retries := flag.Int("retries", 3, "the number of retries for -on-failure retry")
+	retryBackoff := flag.Duration("retry-backoff", 5*time.Second, "the delay before the first retry, doubled for every next retry")
+	dryRun := flag.Bool("dry-run", false, "simulate the actions and report the resulting changes without executing them")
+	parallelism := flag.Int("parallel", 1, "the maximum number of independent actions executed at the same time")
+	maxDepth := flag.Int("max-depth", action.DefaultMaxDepth, "the maximum nesting of actions collected to reach the desired state")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *issuer == "" || *clientID == "" {
+		fmt.Fprintf(os.Stderr, "error: -issuer and -client-id are required\n\n")
+		usage()
+		os.Exit(2)
+	}
+	config := action.NewAuthenticationConfig(*issuer, *clientID, *clientSecret)
+	config.VaultRecoveryRecordUUID = *vaultRecoveryRecord
+	if *tokenCache != "" {
+		cache, err := action.NewTokenCache(*tokenCache, os.Getenv("KEYHUB_TOKEN_CACHE_PASSPHRASE"))
+		if err != nil {
+			fail(err)
+		}
+		config.TokenCache = cache
+	}
+	if *refreshTokens != "" {
+		for _, path := range strings.Split(*refreshTokens, ",") {
+			config.Authenticators = append(config.Authenticators, action.NewRefreshTokenAuthenticator(config, path))
+		}
+	}
+
+	failurePolicy, err := action.ParseFailurePolicy(*onFailure)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n\n", err)
+		usage()
+		os.Exit(2)
+	}
+	options := action.RunOptions{
+		AutoConfirm:  *yes,
+		OnFailure:    failurePolicy,
+		Retries:      *retries,
+		RetryBackoff: *retryBackoff,
+		DryRun:       *dryRun,
+		Parallelism:  *parallelism,
+		Collect: action.CollectOptions{
+			MaxDepth: *maxDepth,
+		},
+	}
+
+	if *planFile != "" {
+		if flag.NArg() != 0 {
+			usage()
+			os.Exit(2)
+		}
+		plan, err := action.ReadPlanFile(*planFile)
+		if err != nil {
+			fail(err)
+		}
+		os.Exit(action.RunPlanWithOptions(config, plan, options))
+	}
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+	state, err := actions.ReadDesiredStateFile(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	root, err := state.Root()
+	if err != nil {
+		fail(fmt.Errorf("invalid desired state: %s", err))
+	}
+	if *exportPlan != "" {
+		err = action.ExportPlan(config, root, *exportPlan, options.Collect)
+		if err != nil {
+			fail(err)
+		}
+	} else {
+		os.Exit(action.RunWithOptions(config, root, options))
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/aquilax/truncate v1.0.0
 	github.com/topicuskeyhub/sdk-go v0.32.0
 	github.com/ttacon/chalk v0.0.0-20160626202418-22c06c80ed31
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/chzyer/readline v1.5.1 // indirect
@@ -19,9 +20,9 @@ require (
 	github.com/golang/protobuf v1.5.4 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/manifoldco/promptui v0.9.0
-	github.com/microsoft/kiota-abstractions-go v1.5.6 // indirect
-	github.com/microsoft/kiota-http-go v1.3.2 // indirect
-	github.com/microsoft/kiota-serialization-json-go v1.0.6 // indirect
+	github.com/microsoft/kiota-abstractions-go v1.5.6
+	github.com/microsoft/kiota-http-go v1.3.2
+	github.com/microsoft/kiota-serialization-json-go v1.0.6
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/schollz/progressbar/v3 v3.14.2
@@ -29,8 +30,8 @@ require (
 	go.opentelemetry.io/otel v1.24.0 // indirect
 	go.opentelemetry.io/otel/metric v1.24.0 // indirect
 	go.opentelemetry.io/otel/trace v1.24.0 // indirect
-	golang.org/x/crypto v0.21.0 // indirect
-	golang.org/x/oauth2 v0.18.0 // indirect
+	golang.org/x/crypto v0.21.0
+	golang.org/x/oauth2 v0.18.0
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/term v0.18.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
@@ -0,0 +1,120 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package keyhubtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/microsoft/kiota-abstractions-go/authentication"
+	nethttplibrary "github.com/microsoft/kiota-http-go"
+	"github.com/topicuskeyhub/automation-framework/action"
+	keyhub "github.com/topicuskeyhub/sdk-go"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+type tokenTransport struct {
+	token string
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func (s *Server) httpClient(account *Account) *http.Client {
+	return &http.Client{Transport: &tokenTransport{token: account.token}}
+}
+
+// Client returns a KeyHub client for the fake server, authenticated as the given account.
+func (s *Server) Client(account *Account) (*keyhub.KeyHubClient, error) {
+	adapter, err := nethttplibrary.NewNetHttpRequestAdapterWithParseNodeFactoryAndSerializationWriterFactoryAndHttpClient(
+		&authentication.AnonymousAuthenticationProvider{}, nil, nil, s.httpClient(account))
+	if err != nil {
+		return nil, err
+	}
+	adapter.SetBaseUrl(s.URL + apiPath)
+	return keyhub.NewKeyHubClient(adapter), nil
+}
+
+// Authenticator returns an authenticator that logs in to the fake server as the given account.
+func (s *Server) Authenticator(account *Account) action.Authenticator {
+	return action.NewHTTPClientAuthenticator(s.httpClient(account), s.URL+apiPath)
+}
+
+// AddAdministrator adds a KeyHub administrator, that is a member of the 'KeyHub administrators'
+// group only, as required for the accounts executing actions.
+func (s *Server) AddAdministrator(username string) *Account {
+	ret := s.AddAccount(username)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret.KeyHubAdmin = true
+	var admins *Group
+	for _, g := range s.groups {
+		if g.Name == "KeyHub administrators" {
+			admins = g
+		}
+	}
+	if admins == nil {
+		admins = s.addGroup("KeyHub administrators")
+	}
+	admins.members[ret.ID] = &membership{
+		account:     ret,
+		rights:      models.NORMAL_GROUPGROUPRIGHTS,
+		vaultAccess: true,
+	}
+	return ret
+}
+
+// Config returns an authentication configuration that logs in to the fake server as the given
+// accounts, and reads the vault recovery key from a vault record on the server.
+func (s *Server) Config(accounts ...*Account) action.AuthenticationConfig {
+	s.mu.Lock()
+	var record *VaultRecord
+	for _, r := range s.vaultRecords {
+		if r.Name == "Vault recovery key" {
+			record = r
+		}
+	}
+	s.mu.Unlock()
+	if record == nil {
+		record = s.AddVaultRecord("Vault recovery key", s.RecoveryKey)
+	}
+
+	ret := action.AuthenticationConfig{
+		Issuer:                  s.URL,
+		VaultRecoveryRecordUUID: record.UUID,
+	}
+	for _, a := range accounts {
+		ret.Authenticators = append(ret.Authenticators, s.Authenticator(a))
+	}
+	return ret
+}
+
+// Environment adds two or three administrators to the server and returns an environment in which
+// they are authenticated as Account1, Account2 and optionally Account3, like SetupEnvironment and
+// AuthenticateAccount3 would.
+func (s *Server) Environment(ctx context.Context, accounts int) (*action.Environment, error) {
+	if accounts < 2 || accounts > 3 {
+		return nil, fmt.Errorf("an environment requires 2 or 3 accounts, not %d", accounts)
+	}
+	admins := make([]*Account, accounts)
+	for i := range admins {
+		admins[i] = s.AddAdministrator(fmt.Sprintf("admin%d", i+1))
+	}
+	config := s.Config(admins...)
+	ret, err := action.SetupEnvironment(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if accounts == 3 {
+		err = action.AuthenticateAccount3(ctx, config, ret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
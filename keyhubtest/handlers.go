@@ -0,0 +1,618 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package keyhubtest
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	usernames := query(r, "username")
+	items := make([]models.AuthAccountable, 0)
+	for _, a := range s.accounts {
+		if matches(uuids, a.UUID) && matches(usernames, a.Username) {
+			items = append(items, s.accountModel(a, query(r, "additional")))
+		}
+	}
+	ret := models.NewAuthAccountLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request, accountID int64) {
+	account := s.accountByID(accountID)
+	if account == nil {
+		s.writeError(w, http.StatusNotFound, "account %d does not exist", accountID)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.accountModel(account, query(r, "additional")))
+}
+
+func (s *Server) listAccountGroups(w http.ResponseWriter, r *http.Request, accountID int64) {
+	account := s.accountByID(accountID)
+	if account == nil {
+		s.writeError(w, http.StatusNotFound, "account %d does not exist", accountID)
+		return
+	}
+	vaultAccess := make([]bool, 0)
+	for _, v := range query(r, "vaultAccess") {
+		vaultAccess = append(vaultAccess, v == "true")
+	}
+	s.writeJSON(w, http.StatusOK, s.accountGroups(account, queryIDs(r, "group"), vaultAccess))
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	names := query(r, "name")
+	items := make([]models.GroupGroupable, 0)
+	for _, g := range s.groups {
+		if matches(uuids, g.UUID) && matches(names, g.Name) {
+			items = append(items, s.groupModel(g, query(r, "additional")))
+		}
+	}
+	ret := models.NewGroupGroupLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+// createGroups creates the groups, with the admins passed in the 'admins' additional object as
+// managers. Only KeyHub administrators can create groups in the fake KeyHub.
+func (s *Server) createGroups(w http.ResponseWriter, r *http.Request, caller *Account) {
+	if !caller.KeyHubAdmin {
+		s.writeError(w, http.StatusForbidden, "%s is not a KeyHub administrator", caller.Username)
+		return
+	}
+	body, err := readJSON[models.GroupGroupLinkableWrapperable](r, models.CreateGroupGroupLinkableWrapperFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid groups: %s", err)
+		return
+	}
+	items := make([]models.GroupGroupable, 0)
+	for _, item := range body.GetItems() {
+		if item.GetName() == nil || *item.GetName() == "" {
+			s.writeError(w, http.StatusBadRequest, "a group requires a name")
+			return
+		}
+		for _, g := range s.groups {
+			if g.Name == *item.GetName() {
+				s.writeError(w, http.StatusConflict, "group %s already exists", g.Name)
+				return
+			}
+		}
+		if item.GetAdditionalObjects() == nil || item.GetAdditionalObjects().GetAdmins() == nil ||
+			len(item.GetAdditionalObjects().GetAdmins().GetItems()) == 0 {
+			s.writeError(w, http.StatusBadRequest, "group %s requires at least one admin", *item.GetName())
+			return
+		}
+		admins := make([]*Account, 0)
+		for _, admin := range item.GetAdditionalObjects().GetAdmins().GetItems() {
+			account, err := s.linkedAccount(admin)
+			if err != nil {
+				s.writeError(w, http.StatusBadRequest, "invalid admin: %s", err)
+				return
+			}
+			admins = append(admins, account)
+		}
+		var classification *GroupClassification
+		if item.GetClassification() != nil {
+			id, err := linkedID(item.GetClassification())
+			if err == nil {
+				classification = s.classificationByID(id)
+			}
+			if classification == nil {
+				s.writeError(w, http.StatusBadRequest, "invalid classification of group %s", *item.GetName())
+				return
+			}
+		}
+		var orgUnit *OrganizationalUnit
+		if item.GetOrganizationalUnit() != nil {
+			id, err := linkedID(item.GetOrganizationalUnit())
+			if err == nil {
+				orgUnit = s.orgUnitByID(id)
+			}
+			if orgUnit == nil {
+				s.writeError(w, http.StatusBadRequest, "invalid organizational unit of group %s", *item.GetName())
+				return
+			}
+		}
+		group := s.addGroup(*item.GetName())
+		group.classification = classification
+		group.orgUnit = orgUnit
+		for _, admin := range admins {
+			s.setMembership(group, admin, models.MANAGER_GROUPGROUPRIGHTS, true)
+		}
+		items = append(items, s.groupModel(group, nil))
+	}
+	ret := models.NewGroupGroupLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusCreated, ret)
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request, groupID int64) {
+	group := s.groupByID(groupID)
+	if group == nil {
+		s.writeError(w, http.StatusNotFound, "group %d does not exist", groupID)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.groupModel(group, query(r, "additional")))
+}
+
+// updateGroup changes the name and organizational unit of the group. Only managers of the group
+// can update it.
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request, caller *Account, groupID int64) {
+	group := s.groupByID(groupID)
+	if group == nil {
+		s.writeError(w, http.StatusNotFound, "group %d does not exist", groupID)
+		return
+	}
+	if !group.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of group %s", caller.Username, group.Name)
+		return
+	}
+	body, err := readJSON[models.GroupGroupable](r, models.CreateGroupGroupFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid group: %s", err)
+		return
+	}
+	if body.GetName() == nil || *body.GetName() == "" {
+		s.writeError(w, http.StatusBadRequest, "a group requires a name")
+		return
+	}
+	for _, g := range s.groups {
+		if g != group && g.Name == *body.GetName() {
+			s.writeError(w, http.StatusConflict, "group %s already exists", g.Name)
+			return
+		}
+	}
+	var orgUnit *OrganizationalUnit
+	if body.GetOrganizationalUnit() != nil {
+		id, err := linkedID(body.GetOrganizationalUnit())
+		if err == nil {
+			orgUnit = s.orgUnitByID(id)
+		}
+		if orgUnit == nil {
+			s.writeError(w, http.StatusBadRequest, "invalid organizational unit of group %s", group.Name)
+			return
+		}
+	}
+	group.Name = *body.GetName()
+	group.orgUnit = orgUnit
+	s.writeJSON(w, http.StatusOK, s.groupModel(group, nil))
+}
+
+func (s *Server) listGroupAccounts(w http.ResponseWriter, r *http.Request, groupID int64) {
+	group := s.groupByID(groupID)
+	if group == nil {
+		s.writeError(w, http.StatusNotFound, "group %d does not exist", groupID)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.groupAccounts(group, queryIDs(r, "account")))
+}
+
+func (s *Server) groupMembership(w http.ResponseWriter, groupID int64, accountID int64) (*Group, *membership) {
+	group := s.groupByID(groupID)
+	if group == nil {
+		s.writeError(w, http.StatusNotFound, "group %d does not exist", groupID)
+		return nil, nil
+	}
+	m, ok := group.members[accountID]
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "account %d is not a member of group %s", accountID, group.Name)
+		return nil, nil
+	}
+	return group, m
+}
+
+func (s *Server) getGroupAccount(w http.ResponseWriter, groupID int64, accountID int64) {
+	group, m := s.groupMembership(w, groupID, accountID)
+	if m == nil {
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.groupAccountModel(group, m))
+}
+
+func (s *Server) updateGroupAccount(w http.ResponseWriter, r *http.Request, caller *Account, groupID int64, accountID int64) {
+	group, m := s.groupMembership(w, groupID, accountID)
+	if m == nil {
+		return
+	}
+	if !group.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of group %s", caller.Username, group.Name)
+		return
+	}
+	body, err := readJSON[models.GroupGroupAccountable](r, models.CreateGroupGroupAccountFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid group account: %s", err)
+		return
+	}
+	if body.GetRights() != nil {
+		m.rights = *body.GetRights()
+	}
+	s.writeJSON(w, http.StatusOK, s.groupAccountModel(group, m))
+}
+
+func (s *Server) deleteGroupAccount(w http.ResponseWriter, caller *Account, groupID int64, accountID int64) {
+	group, m := s.groupMembership(w, groupID, accountID)
+	if m == nil {
+		return
+	}
+	if !group.isManager(caller) && caller != m.account {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of group %s", caller.Username, group.Name)
+		return
+	}
+	delete(group.members, accountID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) recoverVault(w http.ResponseWriter, r *http.Request, caller *Account, groupID int64) {
+	group := s.groupByID(groupID)
+	if group == nil {
+		s.writeError(w, http.StatusNotFound, "group %d does not exist", groupID)
+		return
+	}
+	if !caller.KeyHubAdmin {
+		s.writeError(w, http.StatusForbidden, "%s is not a KeyHub administrator", caller.Username)
+		return
+	}
+	body, err := readJSON[models.VaultVaultRecoveryable](r, models.CreateVaultVaultRecoveryFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid vault recovery: %s", err)
+		return
+	}
+	if body.GetPrivateKey() == nil || *body.GetPrivateKey() != s.RecoveryKey {
+		s.writeError(w, http.StatusBadRequest, "invalid vault recovery key")
+		return
+	}
+	account, err := s.linkedAccount(body.GetAccount())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid vault recovery: %s", err)
+		return
+	}
+	m, ok := group.members[account.ID]
+	if !ok {
+		s.writeError(w, http.StatusBadRequest, "%s is not a member of group %s", account.Username, group.Name)
+		return
+	}
+	m.vaultAccess = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// vaultGroup returns the group, after checking the caller has access to its vault.
+func (s *Server) vaultGroup(w http.ResponseWriter, caller *Account, groupID int64) *Group {
+	group := s.groupByID(groupID)
+	if group == nil {
+		s.writeError(w, http.StatusNotFound, "group %d does not exist", groupID)
+		return nil
+	}
+	m, ok := group.members[caller.ID]
+	if !ok || !m.vaultAccess {
+		s.writeError(w, http.StatusForbidden, "%s has no access to the vault of %s", caller.Username, group.Name)
+		return nil
+	}
+	return group
+}
+
+func (s *Server) listGroupVaultRecords(w http.ResponseWriter, r *http.Request, caller *Account, groupID int64) {
+	group := s.vaultGroup(w, caller, groupID)
+	if group == nil {
+		return
+	}
+	uuids := query(r, "uuid")
+	names := query(r, "name")
+	items := make([]models.VaultVaultRecordable, 0)
+	for _, record := range s.vaultRecords {
+		if record.group == group && matches(uuids, record.UUID) && matches(names, record.Name) {
+			items = append(items, s.vaultRecordModel(record, query(r, "additional")))
+		}
+	}
+	ret := models.NewVaultVaultRecordLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+// moveVaultRecord moves or copies the record to the vault of another group. The caller needs
+// access to both vaults.
+func (s *Server) moveVaultRecord(w http.ResponseWriter, r *http.Request, caller *Account, groupID int64, recordID int64) {
+	group := s.vaultGroup(w, caller, groupID)
+	if group == nil {
+		return
+	}
+	var record *VaultRecord
+	for _, v := range s.vaultRecords {
+		if v.ID == recordID && v.group == group {
+			record = v
+		}
+	}
+	if record == nil {
+		s.writeError(w, http.StatusNotFound, "vault record %d does not exist in the vault of %s", recordID, group.Name)
+		return
+	}
+	body, err := readJSON[models.VaultMoveVaultRecordable](r, models.CreateVaultMoveVaultRecordFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid vault record move: %s", err)
+		return
+	}
+	id, err := linkedID(body.GetGroup())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid vault record move: %s", err)
+		return
+	}
+	target := s.vaultGroup(w, caller, id)
+	if target == nil {
+		return
+	}
+	switch {
+	case body.GetAction() == nil:
+		s.writeError(w, http.StatusBadRequest, "missing vault record move action")
+		return
+	case *body.GetAction() == models.MOVE_VAULTMOVEVAULTRECORDACTION:
+		record.group = target
+	case *body.GetAction() == models.COPY_VAULTMOVEVAULTRECORDACTION:
+		s.addVaultRecord(target, record.Name, record.File)
+	default:
+		s.writeError(w, http.StatusBadRequest, "vault record move action %s is not supported by the fake KeyHub", body.GetAction().String())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listGroupClassifications(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	names := query(r, "name")
+	items := make([]models.GroupGroupClassificationable, 0)
+	for _, c := range s.classifications {
+		if matches(uuids, c.UUID) && matches(names, c.Name) {
+			items = append(items, s.classificationModel(c))
+		}
+	}
+	ret := models.NewGroupGroupClassificationLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) listClients(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	names := query(r, "name")
+	items := make([]models.ClientClientApplicationable, 0)
+	for _, client := range s.clients {
+		if matches(uuids, client.UUID) && matches(names, client.Name) {
+			items = append(items, s.clientModel(client))
+		}
+	}
+	ret := models.NewClientClientApplicationLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) findClient(w http.ResponseWriter, clientID int64) *Client {
+	for _, client := range s.clients {
+		if client.ID == clientID {
+			return client
+		}
+	}
+	s.writeError(w, http.StatusNotFound, "client %d does not exist", clientID)
+	return nil
+}
+
+func (s *Server) listClientPermissions(w http.ResponseWriter, clientID int64) {
+	client := s.findClient(w, clientID)
+	if client == nil {
+		return
+	}
+	items := make([]models.ClientOAuth2ClientPermissionable, 0)
+	for _, p := range client.permissions {
+		items = append(items, s.clientPermissionModel(client, p))
+	}
+	ret := models.NewClientOAuth2ClientPermissionLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) deleteClientPermission(w http.ResponseWriter, caller *Account, clientID int64, permissionID int64) {
+	client := s.findClient(w, clientID)
+	if client == nil {
+		return
+	}
+	if client.owner == nil || !client.owner.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of the owner of %s", caller.Username, client.Name)
+		return
+	}
+	count := len(client.permissions)
+	client.permissions = slices.DeleteFunc(client.permissions, func(p *clientPermission) bool { return p.id == permissionID })
+	if len(client.permissions) == count {
+		s.writeError(w, http.StatusNotFound, "permission %d does not exist on client %s", permissionID, client.Name)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	usernames := query(r, "username")
+	items := make([]models.ServiceaccountServiceAccountable, 0)
+	for _, serviceAccount := range s.serviceAccounts {
+		if matches(uuids, serviceAccount.UUID) && matches(usernames, serviceAccount.Username) {
+			items = append(items, s.serviceAccountModel(serviceAccount))
+		}
+	}
+	ret := models.NewServiceaccountServiceAccountLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) listSystems(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	items := make([]models.ProvisioningProvisionedSystemable, 0)
+	for _, system := range s.systems {
+		if matches(uuids, system.UUID) {
+			items = append(items, s.systemModel(system))
+		}
+	}
+	ret := models.NewProvisioningProvisionedSystemLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) listGroupsOnSystem(w http.ResponseWriter, r *http.Request, systemID int64) {
+	namesInSystem := query(r, "nameInSystem")
+	items := make([]models.ProvisioningGroupOnSystemable, 0)
+	for _, gos := range s.groupsOnSystem {
+		if gos.system.ID == systemID && matches(namesInSystem, gos.NameInSystem) {
+			items = append(items, s.groupOnSystemModel(gos))
+		}
+	}
+	ret := models.NewProvisioningGroupOnSystemLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) findGroupOnSystem(w http.ResponseWriter, systemID int64, gosID int64) *GroupOnSystem {
+	gos := s.groupOnSystemByID(gosID)
+	if gos == nil || gos.system.ID != systemID {
+		s.writeError(w, http.StatusNotFound, "group on system %d does not exist on system %d", gosID, systemID)
+		return nil
+	}
+	return gos
+}
+
+func (s *Server) deleteGroupOnSystem(w http.ResponseWriter, caller *Account, systemID int64, gosID int64) {
+	gos := s.findGroupOnSystem(w, systemID, gosID)
+	if gos == nil {
+		return
+	}
+	if gos.system.owner == nil || !gos.system.owner.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of the owner of %s", caller.Username, gos.system.Name)
+		return
+	}
+	s.groupsOnSystem = slices.DeleteFunc(s.groupsOnSystem, func(g *GroupOnSystem) bool { return g == gos })
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listProvGroups(w http.ResponseWriter, systemID int64, gosID int64) {
+	gos := s.findGroupOnSystem(w, systemID, gosID)
+	if gos == nil {
+		return
+	}
+	items := make([]models.GroupProvisioningGroupable, 0)
+	for _, p := range gos.provGroups {
+		items = append(items, s.provGroupModel(gos, p))
+	}
+	ret := models.NewGroupProvisioningGroupLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) deleteProvGroup(w http.ResponseWriter, caller *Account, systemID int64, gosID int64, provGroupID int64) {
+	gos := s.findGroupOnSystem(w, systemID, gosID)
+	if gos == nil {
+		return
+	}
+	if gos.owner == nil || !gos.owner.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of the owner of %s", caller.Username, gos.NameInSystem)
+		return
+	}
+	count := len(gos.provGroups)
+	gos.provGroups = slices.DeleteFunc(gos.provGroups, func(p *provGroup) bool { return p.id == provGroupID })
+	if len(gos.provGroups) == count {
+		s.writeError(w, http.StatusNotFound, "provisioned group %d does not exist on %s", provGroupID, gos.NameInSystem)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listOrganizationalUnits(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	items := make([]models.OrganizationOrganizationalUnitable, 0)
+	for _, orgUnit := range s.orgUnits {
+		if matches(uuids, orgUnit.UUID) {
+			items = append(items, s.orgUnitModel(orgUnit))
+		}
+	}
+	ret := models.NewOrganizationOrganizationalUnitLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) listOrganizationalUnitAccounts(w http.ResponseWriter, r *http.Request, orgUnitID int64) {
+	orgUnit := s.orgUnitByID(orgUnitID)
+	if orgUnit == nil {
+		s.writeError(w, http.StatusNotFound, "organizational unit %d does not exist", orgUnitID)
+		return
+	}
+	accountIDs := queryIDs(r, "account")
+	items := make([]models.OrganizationOrganizationalUnitAccountable, 0)
+	for _, a := range s.accounts {
+		if _, ok := orgUnit.accounts[a.ID]; ok && matches(accountIDs, a.ID) {
+			items = append(items, s.orgUnitAccountModel(orgUnit, a))
+		}
+	}
+	ret := models.NewOrganizationOrganizationalUnitAccountLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) addOrganizationalUnitAccounts(w http.ResponseWriter, r *http.Request, caller *Account, orgUnitID int64) {
+	orgUnit := s.orgUnitByID(orgUnitID)
+	if orgUnit == nil {
+		s.writeError(w, http.StatusNotFound, "organizational unit %d does not exist", orgUnitID)
+		return
+	}
+	if orgUnit.owner == nil || !orgUnit.owner.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of the owner of organizational unit %s", caller.Username, orgUnit.Name)
+		return
+	}
+	body, err := readJSON[models.OrganizationOrganizationalUnitAccountLinkableWrapperable](r, models.CreateOrganizationOrganizationalUnitAccountLinkableWrapperFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid organizational unit accounts: %s", err)
+		return
+	}
+	items := make([]models.OrganizationOrganizationalUnitAccountable, 0)
+	for _, item := range body.GetItems() {
+		account, err := s.linkedAccount(item)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid organizational unit account: %s", err)
+			return
+		}
+		orgUnit.accounts[account.ID] = account
+		items = append(items, s.orgUnitAccountModel(orgUnit, account))
+	}
+	ret := models.NewOrganizationOrganizationalUnitAccountLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusCreated, ret)
+}
+
+func (s *Server) deleteOrganizationalUnitAccount(w http.ResponseWriter, caller *Account, orgUnitID int64, accountID int64) {
+	orgUnit := s.orgUnitByID(orgUnitID)
+	if orgUnit == nil {
+		s.writeError(w, http.StatusNotFound, "organizational unit %d does not exist", orgUnitID)
+		return
+	}
+	if orgUnit.owner == nil || !orgUnit.owner.isManager(caller) {
+		s.writeError(w, http.StatusForbidden, "%s is not a manager of the owner of organizational unit %s", caller.Username, orgUnit.Name)
+		return
+	}
+	if _, ok := orgUnit.accounts[accountID]; !ok {
+		s.writeError(w, http.StatusNotFound, "account %d is not in organizational unit %s", accountID, orgUnit.Name)
+		return
+	}
+	delete(orgUnit.accounts, accountID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listVaultRecords(w http.ResponseWriter, r *http.Request) {
+	uuids := query(r, "uuid")
+	items := make([]models.VaultVaultRecordable, 0)
+	for _, record := range s.vaultRecords {
+		if matches(uuids, record.UUID) {
+			items = append(items, s.vaultRecordModel(record, query(r, "additional")))
+		}
+	}
+	ret := models.NewVaultVaultRecordLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
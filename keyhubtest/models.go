@@ -0,0 +1,406 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package keyhubtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	jsonserialization "github.com/microsoft/kiota-serialization-json-go"
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, value serialization.Parsable) {
+	writer := jsonserialization.NewJsonSerializationWriter()
+	defer writer.Close()
+	err := writer.WriteObjectValue("", value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	content, err := writer.GetSerializedContent()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(content)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, format string, v ...any) {
+	report := models.NewErrorReport()
+	report.SetCode(action.Ptr(int32(status)))
+	report.SetMessage(action.Ptr(fmt.Sprintf(format, v...)))
+	s.writeJSON(w, status, report)
+}
+
+func readJSON[T any](r *http.Request, factory serialization.ParsableFactory) (T, error) {
+	var ret T
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return ret, err
+	}
+	node, err := jsonserialization.NewJsonParseNode(content)
+	if err != nil {
+		return ret, err
+	}
+	value, err := node.GetObjectValue(factory)
+	if err != nil {
+		return ret, err
+	}
+	ret, ok := value.(T)
+	if !ok {
+		return ret, fmt.Errorf("unexpected body of type %T", value)
+	}
+	return ret, nil
+}
+
+// query returns the values of the query parameter, either repeated or separated by commas.
+func query(r *http.Request, name string) []string {
+	ret := make([]string, 0)
+	for _, v := range r.URL.Query()[name] {
+		ret = append(ret, strings.Split(v, ",")...)
+	}
+	return ret
+}
+
+func queryIDs(r *http.Request, name string) []int64 {
+	ret := make([]int64, 0)
+	for _, v := range query(r, name) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+// matches returns true when no values are given for the filter, or when the value is one of them.
+func matches[T comparable](filter []T, value T) bool {
+	return len(filter) == 0 || slices.Contains(filter, value)
+}
+
+func (s *Server) links(id int64, typeEscaped string, format string, v ...any) []models.RestLinkable {
+	return []models.RestLinkable{s.link("self", id, typeEscaped, format, v...)}
+}
+
+func (s *Server) link(rel string, id int64, typeEscaped string, format string, v ...any) models.RestLinkable {
+	ret := models.NewRestLink()
+	ret.SetRel(action.Ptr(rel))
+	ret.SetId(action.Ptr(id))
+	ret.SetTypeEscaped(action.Ptr(typeEscaped))
+	ret.SetHref(action.Ptr(s.URL + apiPath + fmt.Sprintf(format, v...)))
+	return ret
+}
+
+// linkedID returns the id of the self link of an object passed in a request body.
+func linkedID(linkable models.Linkableable) (int64, error) {
+	if linkable == nil {
+		return 0, errors.New("missing linked object")
+	}
+	return action.SelfID(linkable)
+}
+
+func (s *Server) accountByID(id int64) *Account {
+	for _, a := range s.accounts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) linkedAccount(linkable models.Linkableable) (*Account, error) {
+	id, err := linkedID(linkable)
+	if err != nil {
+		return nil, err
+	}
+	ret := s.accountByID(id)
+	if ret == nil {
+		return nil, fmt.Errorf("account %d does not exist", id)
+	}
+	return ret, nil
+}
+
+func (s *Server) groupByID(id int64) *Group {
+	for _, g := range s.groups {
+		if g.ID == id {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) linkedGroup(linkable models.Linkableable) (*Group, error) {
+	id, err := linkedID(linkable)
+	if err != nil {
+		return nil, err
+	}
+	ret := s.groupByID(id)
+	if ret == nil {
+		return nil, fmt.Errorf("group %d does not exist", id)
+	}
+	return ret, nil
+}
+
+func (s *Server) classificationByID(id int64) *GroupClassification {
+	for _, c := range s.classifications {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Server) groupOnSystemByID(id int64) *GroupOnSystem {
+	for _, g := range s.groupsOnSystem {
+		if g.ID == id {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) orgUnitByID(id int64) *OrganizationalUnit {
+	for _, o := range s.orgUnits {
+		if o.ID == id {
+			return o
+		}
+	}
+	return nil
+}
+
+func (g *Group) isManager(account *Account) bool {
+	m, ok := g.members[account.ID]
+	return ok && m.rights == models.MANAGER_GROUPGROUPRIGHTS
+}
+
+func (g *Group) isMember(account *Account) bool {
+	_, ok := g.members[account.ID]
+	return ok
+}
+
+func (s *Server) accountPrimer(a *Account) models.AuthAccountPrimerable {
+	ret := models.NewAuthAccountPrimer()
+	ret.SetLinks(s.links(a.ID, "auth.Account", "/account/%d", a.ID))
+	ret.SetUuid(action.Ptr(a.UUID))
+	ret.SetUsername(action.Ptr(a.Username))
+	ret.SetDisplayName(action.Ptr(a.Username))
+	return ret
+}
+
+func (s *Server) accountModel(a *Account, additional []string) models.AuthAccountable {
+	ret := models.NewAuthAccount()
+	ret.SetLinks(s.links(a.ID, "auth.Account", "/account/%d", a.ID))
+	ret.SetUuid(action.Ptr(a.UUID))
+	ret.SetUsername(action.Ptr(a.Username))
+	ret.SetDisplayName(action.Ptr(a.Username))
+	if slices.Contains(additional, "groups") {
+		additionalObjects := models.NewAuthAccount_additionalObjects()
+		additionalObjects.SetGroups(s.accountGroups(a, nil, nil))
+		ret.SetAdditionalObjects(additionalObjects)
+	}
+	return ret
+}
+
+func (s *Server) accountGroups(a *Account, groupIDs []int64, vaultAccess []bool) models.GroupAccountGroupLinkableWrapperable {
+	items := make([]models.GroupAccountGroupable, 0)
+	for _, g := range s.groups {
+		m, ok := g.members[a.ID]
+		if !ok || !matches(groupIDs, g.ID) || !matches(vaultAccess, m.vaultAccess) {
+			continue
+		}
+		item := models.NewGroupAccountGroup()
+		item.SetLinks(s.links(g.ID, "group.Group", "/group/%d", g.ID))
+		item.SetUuid(action.Ptr(g.UUID))
+		item.SetName(action.Ptr(g.Name))
+		item.SetRights(action.Ptr(m.rights))
+		items = append(items, item)
+	}
+	ret := models.NewGroupAccountGroupLinkableWrapper()
+	ret.SetItems(items)
+	return ret
+}
+
+func (s *Server) groupPrimer(g *Group) models.GroupGroupPrimerable {
+	if g == nil {
+		return nil
+	}
+	ret := models.NewGroupGroupPrimer()
+	ret.SetLinks(s.links(g.ID, "group.Group", "/group/%d", g.ID))
+	ret.SetUuid(action.Ptr(g.UUID))
+	ret.SetName(action.Ptr(g.Name))
+	return ret
+}
+
+func (s *Server) groupModel(g *Group, additional []string) models.GroupGroupable {
+	ret := models.NewGroupGroup()
+	ret.SetLinks(s.links(g.ID, "group.Group", "/group/%d", g.ID))
+	ret.SetUuid(action.Ptr(g.UUID))
+	ret.SetName(action.Ptr(g.Name))
+	ret.SetAuthorizingGroupAuditing(s.groupPrimer(g.authorizing[models.AUDITING_REQUESTAUTHORIZINGGROUPTYPE]))
+	ret.SetAuthorizingGroupDelegation(s.groupPrimer(g.authorizing[models.DELEGATION_REQUESTAUTHORIZINGGROUPTYPE]))
+	ret.SetAuthorizingGroupMembership(s.groupPrimer(g.authorizing[models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE]))
+	ret.SetAuthorizingGroupProvisioning(s.groupPrimer(g.authorizing[models.PROVISIONING_REQUESTAUTHORIZINGGROUPTYPE]))
+	if g.classification != nil {
+		ret.SetClassification(s.classificationModel(g.classification))
+	}
+	if g.orgUnit != nil {
+		ret.SetOrganizationalUnit(s.orgUnitModel(g.orgUnit))
+	}
+	if slices.Contains(additional, "accounts") {
+		additionalObjects := models.NewGroupGroup_additionalObjects()
+		additionalObjects.SetAccounts(s.groupAccounts(g, nil))
+		ret.SetAdditionalObjects(additionalObjects)
+	}
+	return ret
+}
+
+func (s *Server) classificationModel(c *GroupClassification) models.GroupGroupClassificationable {
+	ret := models.NewGroupGroupClassification()
+	ret.SetLinks(s.links(c.ID, "group.GroupClassification", "/groupclassification/%d", c.ID))
+	ret.SetUuid(action.Ptr(c.UUID))
+	ret.SetName(action.Ptr(c.Name))
+	return ret
+}
+
+func (s *Server) groupAccountModel(g *Group, m *membership) models.GroupGroupAccountable {
+	ret := models.NewGroupGroupAccount()
+	ret.SetLinks([]models.RestLinkable{
+		s.link("self", g.ID, "group.GroupAccount", "/group/%d/account/%d", g.ID, m.account.ID),
+		s.link("koppeling", m.account.ID, "auth.Account", "/account/%d", m.account.ID),
+	})
+	ret.SetUuid(action.Ptr(m.account.UUID))
+	ret.SetUsername(action.Ptr(m.account.Username))
+	ret.SetRights(action.Ptr(m.rights))
+	return ret
+}
+
+func (s *Server) groupAccounts(g *Group, accountIDs []int64) models.GroupGroupAccountLinkableWrapperable {
+	items := make([]models.GroupGroupAccountable, 0)
+	for _, a := range s.accounts {
+		m, ok := g.members[a.ID]
+		if ok && matches(accountIDs, a.ID) {
+			items = append(items, s.groupAccountModel(g, m))
+		}
+	}
+	ret := models.NewGroupGroupAccountLinkableWrapper()
+	ret.SetItems(items)
+	return ret
+}
+
+func (s *Server) systemPrimer(system *System) models.ProvisioningProvisionedSystemPrimerable {
+	ret := models.NewProvisioningProvisionedSystemPrimer()
+	ret.SetLinks(s.links(system.ID, "provisioning.ProvisionedSystem", "/system/%d", system.ID))
+	ret.SetUuid(action.Ptr(system.UUID))
+	ret.SetName(action.Ptr(system.Name))
+	return ret
+}
+
+func (s *Server) systemModel(system *System) models.ProvisioningProvisionedSystemable {
+	ret := models.NewProvisioningProvisionedSystem()
+	ret.SetLinks(s.links(system.ID, "provisioning.ProvisionedSystem", "/system/%d", system.ID))
+	ret.SetUuid(action.Ptr(system.UUID))
+	ret.SetName(action.Ptr(system.Name))
+	ret.SetOwner(s.groupPrimer(system.owner))
+	return ret
+}
+
+func (s *Server) clientModel(client *Client) models.ClientClientApplicationable {
+	ret := models.NewClientClientApplication()
+	ret.SetLinks(s.links(client.ID, "client.ClientApplication", "/client/%d", client.ID))
+	ret.SetUuid(action.Ptr(client.UUID))
+	ret.SetName(action.Ptr(client.Name))
+	ret.SetClientId(action.Ptr(client.ClientID))
+	ret.SetOwner(s.groupPrimer(client.owner))
+	return ret
+}
+
+func (s *Server) serviceAccountModel(serviceAccount *ServiceAccount) models.ServiceaccountServiceAccountable {
+	ret := models.NewServiceaccountServiceAccount()
+	ret.SetLinks(s.links(serviceAccount.ID, "serviceaccount.ServiceAccount", "/serviceaccount/%d", serviceAccount.ID))
+	ret.SetUuid(action.Ptr(serviceAccount.UUID))
+	ret.SetUsername(action.Ptr(serviceAccount.Username))
+	ret.SetTechnicalAdministrator(s.groupPrimer(serviceAccount.admin))
+	ret.SetSystem(s.systemPrimer(serviceAccount.system))
+	return ret
+}
+
+func (s *Server) clientPermissionModel(client *Client, p *clientPermission) models.ClientOAuth2ClientPermissionable {
+	ret := models.NewClientOAuth2ClientPermission()
+	ret.SetLinks(s.links(p.id, "client.OAuth2ClientPermission", "/client/%d/permission/%d", client.ID, p.id))
+	ret.SetValue(action.Ptr(p.permissionType))
+	ret.SetForGroup(s.groupPrimer(p.group))
+	if p.system != nil {
+		ret.SetForSystem(s.systemPrimer(p.system))
+	}
+	return ret
+}
+
+func (s *Server) groupOnSystemModel(gos *GroupOnSystem) models.ProvisioningGroupOnSystemable {
+	ret := models.NewProvisioningGroupOnSystem()
+	ret.SetLinks(s.links(gos.ID, "provisioning.GroupOnSystem", "/system/%d/group/%d", gos.system.ID, gos.ID))
+	ret.SetUuid(action.Ptr(gos.UUID))
+	ret.SetNameInSystem(action.Ptr(gos.NameInSystem))
+	ret.SetDisplayName(action.Ptr(gos.DisplayName))
+	ret.SetTypeEscaped(action.Ptr(gos.Type))
+	ret.SetOwner(s.groupPrimer(gos.owner))
+	ret.SetSystem(s.systemPrimer(gos.system))
+	return ret
+}
+
+func (s *Server) provGroupModel(gos *GroupOnSystem, p *provGroup) models.GroupProvisioningGroupable {
+	ret := models.NewGroupProvisioningGroup()
+	ret.SetLinks(s.links(p.id, "group.ProvisioningGroup", "/system/%d/group/%d/provgroup/%d", gos.system.ID, gos.ID, p.id))
+	ret.SetGroup(s.groupPrimer(p.group))
+	ret.SetGroupOnSystem(s.groupOnSystemModel(gos))
+	return ret
+}
+
+func (s *Server) orgUnitModel(orgUnit *OrganizationalUnit) models.OrganizationOrganizationalUnitable {
+	ret := models.NewOrganizationOrganizationalUnit()
+	ret.SetLinks(s.links(orgUnit.ID, "organization.OrganizationalUnit", "/organizationalunit/%d", orgUnit.ID))
+	ret.SetUuid(action.Ptr(orgUnit.UUID))
+	ret.SetName(action.Ptr(orgUnit.Name))
+	ret.SetOwner(s.groupPrimer(orgUnit.owner))
+	return ret
+}
+
+func (s *Server) orgUnitAccountModel(orgUnit *OrganizationalUnit, a *Account) models.OrganizationOrganizationalUnitAccountable {
+	ret := models.NewOrganizationOrganizationalUnitAccount()
+	ret.SetLinks([]models.RestLinkable{
+		s.link("self", orgUnit.ID, "organization.OrganizationalUnitAccount", "/organizationalunit/%d/account/%d", orgUnit.ID, a.ID),
+		s.link("koppeling", a.ID, "auth.Account", "/account/%d", a.ID),
+	})
+	ret.SetUuid(action.Ptr(a.UUID))
+	ret.SetUsername(action.Ptr(a.Username))
+	return ret
+}
+
+func (s *Server) vaultRecordModel(record *VaultRecord, additional []string) models.VaultVaultRecordable {
+	ret := models.NewVaultVaultRecord()
+	if record.group != nil {
+		ret.SetLinks(s.links(record.ID, "vault.VaultRecord", "/group/%d/vault/record/%d", record.group.ID, record.ID))
+	} else {
+		ret.SetLinks(s.links(record.ID, "vault.VaultRecord", "/vaultrecord/%d", record.ID))
+	}
+	ret.SetUuid(action.Ptr(record.UUID))
+	ret.SetName(action.Ptr(record.Name))
+	if slices.Contains(additional, "secret") {
+		secret := models.NewVaultVaultRecordSecrets()
+		secret.SetFile(action.Ptr(record.File))
+		additionalObjects := models.NewVaultVaultRecord_additionalObjects()
+		additionalObjects.SetSecret(secret)
+		ret.SetAdditionalObjects(additionalObjects)
+	}
+	return ret
+}
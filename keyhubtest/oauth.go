@@ -0,0 +1,146 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package keyhubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const oauthPath = "/login/oauth2"
+
+// RefreshToken issues a refresh token for the account, for use with the token endpoint of the
+// server. Every refresh returns a new refresh token and invalidates the old one, like KeyHub does.
+func (s *Server) RefreshToken(account *Account) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newRefreshToken(account)
+}
+
+func (s *Server) newRefreshToken(account *Account) string {
+	if s.refreshTokens == nil {
+		s.refreshTokens = make(map[string]*Account)
+	}
+	ret := fmt.Sprintf("refresh-%d-%d", account.ID, s.newID())
+	s.refreshTokens[ret] = account
+	return ret
+}
+
+// ClientCredentials registers a client whose tokens, obtained with the client credentials grant,
+// authenticate as the account, and returns its client id and secret.
+func (s *Server) ClientCredentials(account *Account) (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clientSecrets == nil {
+		s.clientSecrets = make(map[string]clientSecret)
+	}
+	id := s.newID()
+	clientID := fmt.Sprintf("client-%d", id)
+	secret := fmt.Sprintf("secret-%d", id)
+	s.clientSecrets[clientID] = clientSecret{secret: secret, account: account}
+	return clientID, secret
+}
+
+// LoginWithDevice queues the account to log in at the next device authorization started on the
+// server, as if the user opened the verification URI and logged in as that account.
+func (s *Server) LoginWithDevice(account *Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deviceLogins = append(s.deviceLogins, account)
+}
+
+func (s *Server) serveOAuth(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "GET /.well-known/openid-configuration":
+		writeOAuth(w, http.StatusOK, map[string]any{
+			"issuer":                        s.URL,
+			"authorization_endpoint":        s.URL + oauthPath + "/authorize",
+			"token_endpoint":                s.URL + oauthPath + "/token",
+			"device_authorization_endpoint": s.URL + oauthPath + "/device",
+		})
+	case "POST " + oauthPath + "/device":
+		s.deviceAuthorization(w)
+	case "POST " + oauthPath + "/token":
+		s.token(w, r)
+	default:
+		s.writeError(w, http.StatusNotFound, "unknown path %s", r.URL.Path)
+	}
+}
+
+func (s *Server) deviceAuthorization(w http.ResponseWriter) {
+	if len(s.deviceLogins) == 0 {
+		writeOAuth(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	if s.deviceCodes == nil {
+		s.deviceCodes = make(map[string]*Account)
+	}
+	code := fmt.Sprintf("device-%d", s.newID())
+	s.deviceCodes[code] = s.deviceLogins[0]
+	s.deviceLogins = s.deviceLogins[1:]
+	writeOAuth(w, http.StatusOK, map[string]any{
+		"device_code":      code,
+		"user_code":        code,
+		"verification_uri": s.URL + oauthPath + "/verify",
+		"expires_in":       60,
+		"interval":         1,
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	var account *Account
+	switch r.PostFormValue("grant_type") {
+	case "refresh_token":
+		token := r.PostFormValue("refresh_token")
+		account = s.refreshTokens[token]
+		delete(s.refreshTokens, token)
+	case "client_credentials":
+		clientID, secret, ok := r.BasicAuth()
+		if !ok {
+			clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+		}
+		if client, ok := s.clientSecrets[clientID]; ok && client.secret == secret {
+			account = client.account
+		}
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		code := r.PostFormValue("device_code")
+		account = s.deviceCodes[code]
+		delete(s.deviceCodes, code)
+	default:
+		writeOAuth(w, http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"})
+		return
+	}
+	if account == nil {
+		writeOAuth(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+		return
+	}
+	if r.PostFormValue("grant_type") == "client_credentials" {
+		// No refresh token is issued for the client credentials grant.
+		writeOAuth(w, http.StatusOK, map[string]any{
+			"access_token": account.token,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+		return
+	}
+	writeOAuth(w, http.StatusOK, map[string]any{
+		"access_token":  account.token,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": s.newRefreshToken(account),
+	})
+}
+
+// clientSecret is the secret of a client registered with ClientCredentials.
+type clientSecret struct {
+	secret  string
+	account *Account
+}
+
+func writeOAuth(w http.ResponseWriter, status int, value map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
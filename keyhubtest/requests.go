@@ -0,0 +1,578 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+package keyhubtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/topicuskeyhub/automation-framework/action"
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+func (s *Server) requestByID(id int64) *request {
+	for _, r := range s.requests {
+		if r.id == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// addRequest stores a new request with status REQUESTED, submitted by the requester.
+func (s *Server) addRequest(requester *Account, model models.RequestModificationRequestable) *request {
+	id := s.newID()
+	model.SetLinks(s.links(id, "request.ModificationRequest", "/request/%d", id))
+	model.SetStatus(action.Ptr(models.REQUESTED_REQUESTMODIFICATIONREQUESTSTATUS))
+	ret := &request{
+		id:        id,
+		requester: requester,
+		model:     model,
+	}
+	s.requests = append(s.requests, ret)
+	return ret
+}
+
+func (s *Server) listRequests(w http.ResponseWriter, r *http.Request) {
+	statuses := query(r, "status")
+	updateTypes := query(r, "updateGroupMembershipType")
+	groupIDs := queryIDs(r, "group")
+	accountIDs := queryIDs(r, "accountToUpdate")
+	items := make([]models.RequestModificationRequestable, 0)
+	for _, req := range s.requests {
+		if !matches(statuses, req.model.GetStatus().String()) {
+			continue
+		}
+		if len(groupIDs) > 0 {
+			id, err := linkedID(req.model.GetGroup())
+			if err != nil || !matches(groupIDs, id) {
+				continue
+			}
+		}
+		if len(updateTypes) > 0 || len(accountIDs) > 0 {
+			update, ok := req.model.(*models.RequestUpdateGroupMembershipRequest)
+			if !ok || !matches(updateTypes, update.GetUpdateGroupMembershipType().String()) {
+				continue
+			}
+			id, err := linkedID(update.GetAccountToUpdate())
+			if err != nil || !matches(accountIDs, id) {
+				continue
+			}
+		}
+		items = append(items, req.model)
+	}
+	ret := models.NewRequestModificationRequestLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) getRequest(w http.ResponseWriter, requestID int64) {
+	req := s.requestByID(requestID)
+	if req == nil {
+		s.writeError(w, http.StatusNotFound, "request %d does not exist", requestID)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, req.model)
+}
+
+func (s *Server) submitRequests(w http.ResponseWriter, r *http.Request, caller *Account) {
+	body, err := readJSON[models.RequestModificationRequestLinkableWrapperable](r, models.CreateRequestModificationRequestLinkableWrapperFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid requests: %s", err)
+		return
+	}
+	for _, item := range body.GetItems() {
+		err := s.checkRequester(caller, item)
+		if err != nil {
+			s.writeError(w, http.StatusForbidden, "cannot submit request: %s", err)
+			return
+		}
+	}
+	items := make([]models.RequestModificationRequestable, 0)
+	for _, item := range body.GetItems() {
+		items = append(items, s.addRequest(caller, item).model)
+	}
+	ret := models.NewRequestModificationRequestLinkableWrapper()
+	ret.SetItems(items)
+	s.writeJSON(w, http.StatusCreated, ret)
+}
+
+func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request, caller *Account, requestID int64) {
+	req := s.requestByID(requestID)
+	if req == nil {
+		s.writeError(w, http.StatusNotFound, "request %d does not exist", requestID)
+		return
+	}
+	body, err := readJSON[models.RequestModificationRequestable](r, models.CreateRequestModificationRequestFromDiscriminatorValue)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid request: %s", err)
+		return
+	}
+	if *req.model.GetStatus() != models.REQUESTED_REQUESTMODIFICATIONREQUESTSTATUS {
+		s.writeError(w, http.StatusBadRequest, "request %d has already been handled", requestID)
+		return
+	}
+	if body.GetStatus() == nil || *body.GetStatus() == models.REQUESTED_REQUESTMODIFICATIONREQUESTSTATUS {
+		s.writeError(w, http.StatusBadRequest, "request %d must be allowed or denied", requestID)
+		return
+	}
+	if caller == req.requester {
+		s.writeError(w, http.StatusForbidden, "%s cannot handle a request submitted by themselves", caller.Username)
+		return
+	}
+	err = s.checkAccepter(caller, req.model)
+	if err != nil {
+		s.writeError(w, http.StatusForbidden, "cannot handle request: %s", err)
+		return
+	}
+	if *body.GetStatus() == models.ALLOWED_REQUESTMODIFICATIONREQUESTSTATUS {
+		err = s.apply(req, body)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "cannot allow request: %s", err)
+			return
+		}
+	}
+	req.model.SetStatus(body.GetStatus())
+	req.model.SetFeedback(body.GetFeedback())
+	s.writeJSON(w, http.StatusOK, req.model)
+}
+
+// checkRequester verifies the caller is allowed to submit the request.
+func (s *Server) checkRequester(caller *Account, model models.RequestModificationRequestable) error {
+	switch req := model.(type) {
+	case *models.RequestAddGroupAdminRequest:
+		if !caller.KeyHubAdmin {
+			return fmt.Errorf("%s is not a KeyHub administrator", caller.Username)
+		}
+		return nil
+	case *models.RequestUpdateGroupMembershipRequest, *models.RequestChangeGroupClassificationRequest, *models.RequestRemoveGroupRequest,
+		*models.RequestGrantGroupOnSystemRequest, *models.RequestCreateGroupOnSystemRequest:
+		return s.checkManager(caller, req.GetGroup())
+	case *models.RequestSetupAuthorizingGroupRequest:
+		return s.checkManager(caller, req.GetRequestingGroup())
+	case *models.RequestTransferGroupOnSystemOwnershipRequest:
+		gos, err := s.linkedGroupOnSystem(req.GetGroupOnSystem())
+		if err != nil {
+			return err
+		}
+		if gos.owner == nil || !gos.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, gos.NameInSystem)
+		}
+		return nil
+	case *models.RequestTransferProvisionedSystemOwnershipRequest:
+		system, err := s.linkedSystem(req.GetSystem())
+		if err != nil {
+			return err
+		}
+		if system.owner == nil || !system.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, system.Name)
+		}
+		return nil
+	case *models.RequestTransferApplicationOwnershipRequest:
+		client, err := s.linkedClient(req.GetApplication())
+		if err != nil {
+			return err
+		}
+		if client.owner == nil || !client.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, client.Name)
+		}
+		return nil
+	case *models.RequestTransferServiceAccountAdministrationRequest:
+		serviceAccount, err := s.linkedServiceAccount(req.GetServiceAccount())
+		if err != nil {
+			return err
+		}
+		if serviceAccount.admin == nil || !serviceAccount.admin.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the administrator of %s", caller.Username, serviceAccount.Username)
+		}
+		return nil
+	case *models.RequestGrantClientPermissionRequest:
+		client, err := s.linkedClient(req.GetApplication())
+		if err != nil {
+			return err
+		}
+		if client.owner == nil || !client.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, client.Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("requests of type %T are not supported by the fake KeyHub", model)
+}
+
+// checkAccepter verifies the caller is allowed to allow or deny the request.
+func (s *Server) checkAccepter(caller *Account, model models.RequestModificationRequestable) error {
+	switch req := model.(type) {
+	case *models.RequestAddGroupAdminRequest, *models.RequestRemoveGroupRequest, *models.RequestChangeGroupClassificationRequest:
+		if !caller.KeyHubAdmin {
+			return fmt.Errorf("%s is not a KeyHub administrator", caller.Username)
+		}
+		return nil
+	case *models.RequestUpdateGroupMembershipRequest:
+		group, err := s.linkedGroup(req.GetGroup())
+		if err != nil {
+			return err
+		}
+		authorizing := group.authorizing[models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE]
+		if authorizing == nil {
+			return s.checkManager(caller, req.GetGroup())
+		}
+		if !authorizing.isMember(caller) {
+			return fmt.Errorf("%s is not a member of %s, authorizing memberships of %s", caller.Username, authorizing.Name, group.Name)
+		}
+		return nil
+	case *models.RequestSetupAuthorizingGroupRequest:
+		return s.checkManager(caller, req.GetGroup())
+	case *models.RequestTransferGroupOnSystemOwnershipRequest, *models.RequestTransferProvisionedSystemOwnershipRequest,
+		*models.RequestTransferApplicationOwnershipRequest, *models.RequestTransferServiceAccountAdministrationRequest:
+		return s.checkManager(caller, req.GetGroup())
+	case *models.RequestGrantGroupOnSystemRequest:
+		gos, err := s.linkedGroupOnSystem(req.GetGroupOnSystem())
+		if err != nil {
+			return err
+		}
+		if gos.owner == nil || !gos.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, gos.NameInSystem)
+		}
+		return nil
+	case *models.RequestCreateGroupOnSystemRequest:
+		system, err := s.linkedSystem(req.GetSystem())
+		if err != nil {
+			return err
+		}
+		if system.owner == nil || !system.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, system.Name)
+		}
+		return nil
+	case *models.RequestGrantClientPermissionRequest:
+		if req.GetSystem() == nil {
+			return s.checkManager(caller, req.GetGroup())
+		}
+		system, err := s.linkedSystem(req.GetSystem())
+		if err != nil {
+			return err
+		}
+		if system.owner == nil || !system.owner.isManager(caller) {
+			return fmt.Errorf("%s is not a manager of the owner of %s", caller.Username, system.Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("requests of type %T are not supported by the fake KeyHub", model)
+}
+
+func (s *Server) checkManager(caller *Account, linkable models.Linkableable) error {
+	group, err := s.linkedGroup(linkable)
+	if err != nil {
+		return err
+	}
+	if !group.isManager(caller) {
+		return fmt.Errorf("%s is not a manager of group %s", caller.Username, group.Name)
+	}
+	return nil
+}
+
+func (s *Server) linkedGroupOnSystem(linkable models.Linkableable) (*GroupOnSystem, error) {
+	id, err := linkedID(linkable)
+	if err != nil {
+		return nil, err
+	}
+	ret := s.groupOnSystemByID(id)
+	if ret == nil {
+		return nil, fmt.Errorf("group on system %d does not exist", id)
+	}
+	return ret, nil
+}
+
+func (s *Server) linkedSystem(linkable models.Linkableable) (*System, error) {
+	id, err := linkedID(linkable)
+	if err != nil {
+		return nil, err
+	}
+	for _, system := range s.systems {
+		if system.ID == id {
+			return system, nil
+		}
+	}
+	return nil, fmt.Errorf("system %d does not exist", id)
+}
+
+func (s *Server) linkedClient(linkable models.Linkableable) (*Client, error) {
+	id, err := linkedID(linkable)
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range s.clients {
+		if client.ID == id {
+			return client, nil
+		}
+	}
+	return nil, fmt.Errorf("client %d does not exist", id)
+}
+
+func (s *Server) linkedServiceAccount(linkable models.Linkableable) (*ServiceAccount, error) {
+	id, err := linkedID(linkable)
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceAccount := range s.serviceAccounts {
+		if serviceAccount.ID == id {
+			return serviceAccount, nil
+		}
+	}
+	return nil, fmt.Errorf("service account %d does not exist", id)
+}
+
+// apply performs the changes of an allowed request. The body is the request as sent by the
+// accepter, which may carry additional data such as the vault recovery key.
+func (s *Server) apply(req *request, body models.RequestModificationRequestable) error {
+	switch model := req.model.(type) {
+	case *models.RequestAddGroupAdminRequest:
+		return s.applyAddGroupAdmin(req, model, body)
+	case *models.RequestUpdateGroupMembershipRequest:
+		return s.applyUpdateGroupMembership(req, model)
+	case *models.RequestRemoveGroupRequest:
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		s.groups = slices.DeleteFunc(s.groups, func(g *Group) bool { return g == group })
+		return nil
+	case *models.RequestChangeGroupClassificationRequest:
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		id, err := linkedID(model.GetGroupClassification())
+		if err != nil {
+			return err
+		}
+		classification := s.classificationByID(id)
+		if classification == nil {
+			return fmt.Errorf("group classification %d does not exist", id)
+		}
+		group.classification = classification
+		return nil
+	case *models.RequestSetupAuthorizingGroupRequest:
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		requesting, err := s.linkedGroup(model.GetRequestingGroup())
+		if err != nil {
+			return err
+		}
+		if model.GetAuthorizingGroupType() == nil {
+			return errors.New("missing authorizing group type")
+		}
+		authType := *model.GetAuthorizingGroupType()
+		if model.GetConnect() != nil && *model.GetConnect() {
+			group.authorizing[authType] = requesting
+		} else if group.authorizing[authType] == requesting {
+			delete(group.authorizing, authType)
+		} else {
+			return fmt.Errorf("group %s is not connected to %s", requesting.Name, group.Name)
+		}
+		return nil
+	case *models.RequestTransferGroupOnSystemOwnershipRequest:
+		gos, err := s.linkedGroupOnSystem(model.GetGroupOnSystem())
+		if err != nil {
+			return err
+		}
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		gos.owner = group
+		return nil
+	case *models.RequestTransferProvisionedSystemOwnershipRequest:
+		system, err := s.linkedSystem(model.GetSystem())
+		if err != nil {
+			return err
+		}
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		system.owner = group
+		return nil
+	case *models.RequestTransferApplicationOwnershipRequest:
+		client, err := s.linkedClient(model.GetApplication())
+		if err != nil {
+			return err
+		}
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		client.owner = group
+		return nil
+	case *models.RequestTransferServiceAccountAdministrationRequest:
+		serviceAccount, err := s.linkedServiceAccount(model.GetServiceAccount())
+		if err != nil {
+			return err
+		}
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		serviceAccount.admin = group
+		return nil
+	case *models.RequestCreateGroupOnSystemRequest:
+		return s.applyCreateGroupOnSystem(model)
+	case *models.RequestGrantGroupOnSystemRequest:
+		gos, err := s.linkedGroupOnSystem(model.GetGroupOnSystem())
+		if err != nil {
+			return err
+		}
+		group, err := s.linkedGroup(model.GetGroup())
+		if err != nil {
+			return err
+		}
+		for _, p := range gos.provGroups {
+			if p.group == group {
+				return fmt.Errorf("group %s is already provisioned to %s", group.Name, gos.NameInSystem)
+			}
+		}
+		gos.provGroups = append(gos.provGroups, &provGroup{id: s.newID(), group: group})
+		return nil
+	case *models.RequestGrantClientPermissionRequest:
+		client, err := s.linkedClient(model.GetApplication())
+		if err != nil {
+			return err
+		}
+		if model.GetPermissionType() == nil {
+			return errors.New("missing permission type")
+		}
+		var group *Group
+		var system *System
+		if model.GetSystem() != nil {
+			system, err = s.linkedSystem(model.GetSystem())
+		} else {
+			group, err = s.linkedGroup(model.GetGroup())
+		}
+		if err != nil {
+			return err
+		}
+		s.addClientPermission(client, *model.GetPermissionType(), group, system)
+		return nil
+	}
+	return fmt.Errorf("requests of type %T are not supported by the fake KeyHub", req.model)
+}
+
+// applyAddGroupAdmin makes the new admin a manager of the group. When the memberships of the group
+// are authorized by another group, KeyHub forwards the request as an update group membership
+// request to be handled by the authorizing group.
+func (s *Server) applyAddGroupAdmin(req *request, model *models.RequestAddGroupAdminRequest, body models.RequestModificationRequestable) error {
+	group, err := s.linkedGroup(model.GetGroup())
+	if err != nil {
+		return err
+	}
+	account, err := s.linkedAccount(model.GetNewAdmin())
+	if err != nil {
+		return err
+	}
+	vaultAccess := false
+	if addAdmin, ok := body.(models.RequestAddGroupAdminRequestable); ok && addAdmin.GetPrivateKey() != nil {
+		if *addAdmin.GetPrivateKey() != s.RecoveryKey {
+			return errors.New("invalid vault recovery key")
+		}
+		vaultAccess = true
+	}
+
+	if group.authorizing[models.MEMBERSHIP_REQUESTAUTHORIZINGGROUPTYPE] != nil {
+		updateType := models.ADD_REQUESTUPDATEGROUPMEMBERSHIPTYPE
+		if group.isMember(account) {
+			updateType = models.MODIFY_REQUESTUPDATEGROUPMEMBERSHIPTYPE
+		}
+		forward := models.NewRequestUpdateGroupMembershipRequest()
+		forward.SetGroup(s.groupPrimer(group))
+		forward.SetAccountToUpdate(s.accountPrimer(account))
+		forward.SetRights(action.Ptr(models.MANAGER_GROUPGROUPRIGHTS))
+		forward.SetUpdateGroupMembershipType(&updateType)
+		forward.SetComment(model.GetComment())
+		s.addRequest(req.requester, forward).vaultAccess = vaultAccess
+		return nil
+	}
+	s.setMembership(group, account, models.MANAGER_GROUPGROUPRIGHTS, vaultAccess)
+	return nil
+}
+
+func (s *Server) applyUpdateGroupMembership(req *request, model *models.RequestUpdateGroupMembershipRequest) error {
+	group, err := s.linkedGroup(model.GetGroup())
+	if err != nil {
+		return err
+	}
+	account, err := s.linkedAccount(model.GetAccountToUpdate())
+	if err != nil {
+		return err
+	}
+	if model.GetUpdateGroupMembershipType() == nil {
+		return errors.New("missing update group membership type")
+	}
+	switch *model.GetUpdateGroupMembershipType() {
+	case models.REMOVE_REQUESTUPDATEGROUPMEMBERSHIPTYPE:
+		if !group.isMember(account) {
+			return fmt.Errorf("%s is not a member of group %s", account.Username, group.Name)
+		}
+		delete(group.members, account.ID)
+	case models.MODIFY_REQUESTUPDATEGROUPMEMBERSHIPTYPE:
+		if !group.isMember(account) {
+			return fmt.Errorf("%s is not a member of group %s", account.Username, group.Name)
+		}
+		fallthrough
+	default:
+		rights := models.NORMAL_GROUPGROUPRIGHTS
+		if model.GetRights() != nil {
+			rights = *model.GetRights()
+		}
+		s.setMembership(group, account, rights, req.vaultAccess)
+	}
+	return nil
+}
+
+func (s *Server) applyCreateGroupOnSystem(model *models.RequestCreateGroupOnSystemRequest) error {
+	system, err := s.linkedSystem(model.GetSystem())
+	if err != nil {
+		return err
+	}
+	owner, err := s.linkedGroup(model.GetGroup())
+	if err != nil {
+		return err
+	}
+	if model.GetNameInSystem() == nil || model.GetGroupOnSystemType() == nil {
+		return errors.New("missing name in system or group on system type")
+	}
+	for _, gos := range s.groupsOnSystem {
+		if gos.system == system && gos.NameInSystem == *model.GetNameInSystem() {
+			return fmt.Errorf("group on system %s already exists on %s", gos.NameInSystem, system.Name)
+		}
+	}
+	id := s.newID()
+	gos := &GroupOnSystem{
+		ID:           id,
+		UUID:         fakeUUID(id),
+		NameInSystem: *model.GetNameInSystem(),
+		DisplayName:  *model.GetNameInSystem(),
+		Type:         *model.GetGroupOnSystemType(),
+		system:       system,
+		owner:        owner,
+	}
+	if model.GetDisplayName() != nil {
+		gos.DisplayName = *model.GetDisplayName()
+	}
+	s.groupsOnSystem = append(s.groupsOnSystem, gos)
+	return nil
+}
+
+// setMembership adds or updates the membership of the account, never revoking vault access.
+func (s *Server) setMembership(group *Group, account *Account, rights models.GroupGroupRights, vaultAccess bool) {
+	m, ok := group.members[account.ID]
+	if !ok {
+		m = &membership{account: account}
+		group.members[account.ID] = m
+	}
+	m.rights = rights
+	m.vaultAccess = m.vaultAccess || vaultAccess
+}
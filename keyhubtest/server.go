@@ -0,0 +1,625 @@
+// Copyright (c) Topicus Security B.V.
+// SPDX-License-Identifier: APSL-2.0
+
+// Package keyhubtest provides an in-memory fake of the Topicus KeyHub API, to test actions and
+// collected plans without a live KeyHub. Fixtures are added with the Add methods of Server, the
+// resulting state can be inspected with the other methods of Server.
+package keyhubtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/topicuskeyhub/sdk-go/models"
+)
+
+const apiPath = "/keyhub/rest/v1"
+
+type Account struct {
+	ID          int64
+	UUID        string
+	Username    string
+	KeyHubAdmin bool
+	token       string
+}
+
+type Group struct {
+	ID             int64
+	UUID           string
+	Name           string
+	classification *GroupClassification
+	orgUnit        *OrganizationalUnit
+	members        map[int64]*membership
+	authorizing    map[models.RequestAuthorizingGroupType]*Group
+}
+
+type GroupClassification struct {
+	ID   int64
+	UUID string
+	Name string
+}
+
+type membership struct {
+	account     *Account
+	rights      models.GroupGroupRights
+	vaultAccess bool
+}
+
+type System struct {
+	ID    int64
+	UUID  string
+	Name  string
+	owner *Group
+}
+
+type Client struct {
+	ID          int64
+	UUID        string
+	Name        string
+	ClientID    string
+	owner       *Group
+	permissions []*clientPermission
+}
+
+// clientPermission is a permission held by a client on either a group or a system.
+type clientPermission struct {
+	id             int64
+	permissionType models.ClientOAuth2ClientPermissionType
+	group          *Group
+	system         *System
+}
+
+type ServiceAccount struct {
+	ID       int64
+	UUID     string
+	Username string
+	system   *System
+	admin    *Group
+}
+
+type GroupOnSystem struct {
+	ID           int64
+	UUID         string
+	NameInSystem string
+	DisplayName  string
+	Type         models.ProvisioningGroupOnSystemType
+	system       *System
+	owner        *Group
+	provGroups   []*provGroup
+}
+
+// provGroup links a group to a group on system, to provision the members of the group.
+type provGroup struct {
+	id    int64
+	group *Group
+}
+
+type OrganizationalUnit struct {
+	ID       int64
+	UUID     string
+	Name     string
+	owner    *Group
+	accounts map[int64]*Account
+}
+
+// VaultRecord is a record in the vault of a group, or in the personal vault of the caller when it
+// has no group.
+type VaultRecord struct {
+	ID    int64
+	UUID  string
+	Name  string
+	File  string
+	group *Group
+}
+
+type request struct {
+	id          int64
+	requester   *Account
+	model       models.RequestModificationRequestable
+	vaultAccess bool
+}
+
+// Server is a fake KeyHub API server. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	// RecoveryKey is the vault recovery key that must be passed when granting vault access.
+	RecoveryKey string
+
+	mu              sync.Mutex
+	nextID          int64
+	accounts        []*Account
+	groups          []*Group
+	classifications []*GroupClassification
+	systems         []*System
+	clients         []*Client
+	serviceAccounts []*ServiceAccount
+	groupsOnSystem  []*GroupOnSystem
+	orgUnits        []*OrganizationalUnit
+	vaultRecords    []*VaultRecord
+	requests        []*request
+	refreshTokens   map[string]*Account
+	deviceCodes     map[string]*Account
+	deviceLogins    []*Account
+	clientSecrets   map[string]clientSecret
+}
+
+// NewServer starts a new fake KeyHub API server. The caller should call Close when finished, to
+// shut it down.
+func NewServer() *Server {
+	s := &Server{
+		RecoveryKey: "fake-vault-recovery-key",
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func fakeUUID(id int64) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", id)
+}
+
+func (s *Server) AddAccount(username string) *Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &Account{
+		ID:       id,
+		UUID:     fakeUUID(id),
+		Username: username,
+		token:    fmt.Sprintf("token-%d", id),
+	}
+	s.accounts = append(s.accounts, ret)
+	return ret
+}
+
+func (s *Server) AddGroup(name string) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addGroup(name)
+}
+
+func (s *Server) addGroup(name string) *Group {
+	id := s.newID()
+	ret := &Group{
+		ID:          id,
+		UUID:        fakeUUID(id),
+		Name:        name,
+		members:     make(map[int64]*membership),
+		authorizing: make(map[models.RequestAuthorizingGroupType]*Group),
+	}
+	s.groups = append(s.groups, ret)
+	return ret
+}
+
+func (s *Server) AddGroupClassification(name string) *GroupClassification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &GroupClassification{
+		ID:   id,
+		UUID: fakeUUID(id),
+		Name: name,
+	}
+	s.classifications = append(s.classifications, ret)
+	return ret
+}
+
+// AddMember adds the account to the group, with access to the vault of the group.
+func (s *Server) AddMember(group *Group, account *Account, rights models.GroupGroupRights) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group.members[account.ID] = &membership{
+		account:     account,
+		rights:      rights,
+		vaultAccess: true,
+	}
+}
+
+func (s *Server) SetAuthorizingGroup(subject *Group, authType models.RequestAuthorizingGroupType, authorizing *Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if authorizing == nil {
+		delete(subject.authorizing, authType)
+	} else {
+		subject.authorizing[authType] = authorizing
+	}
+}
+
+func (s *Server) AddSystem(name string, owner *Group) *System {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &System{
+		ID:    id,
+		UUID:  fakeUUID(id),
+		Name:  name,
+		owner: owner,
+	}
+	s.systems = append(s.systems, ret)
+	return ret
+}
+
+func (s *Server) AddClient(name string, clientID string, owner *Group) *Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &Client{
+		ID:       id,
+		UUID:     fakeUUID(id),
+		Name:     name,
+		ClientID: clientID,
+		owner:    owner,
+	}
+	s.clients = append(s.clients, ret)
+	return ret
+}
+
+func (s *Server) AddServiceAccount(system *System, username string, admin *Group) *ServiceAccount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &ServiceAccount{
+		ID:       id,
+		UUID:     fakeUUID(id),
+		Username: username,
+		system:   system,
+		admin:    admin,
+	}
+	s.serviceAccounts = append(s.serviceAccounts, ret)
+	return ret
+}
+
+// AddClientPermission grants the permission on the group or system to the client.
+func (s *Server) AddClientPermission(client *Client, permissionType models.ClientOAuth2ClientPermissionType, group *Group, system *System) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addClientPermission(client, permissionType, group, system)
+}
+
+func (s *Server) addClientPermission(client *Client, permissionType models.ClientOAuth2ClientPermissionType, group *Group, system *System) {
+	client.permissions = append(client.permissions, &clientPermission{
+		id:             s.newID(),
+		permissionType: permissionType,
+		group:          group,
+		system:         system,
+	})
+}
+
+func (s *Server) AddGroupOnSystem(system *System, nameInSystem string, displayName string, owner *Group) *GroupOnSystem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &GroupOnSystem{
+		ID:           id,
+		UUID:         fakeUUID(id),
+		NameInSystem: nameInSystem,
+		DisplayName:  displayName,
+		system:       system,
+		owner:        owner,
+	}
+	s.groupsOnSystem = append(s.groupsOnSystem, ret)
+	return ret
+}
+
+func (s *Server) AddProvisionedGroup(gos *GroupOnSystem, group *Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	gos.provGroups = append(gos.provGroups, &provGroup{id: s.newID(), group: group})
+}
+
+func (s *Server) AddOrganizationalUnit(name string, owner *Group) *OrganizationalUnit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID()
+	ret := &OrganizationalUnit{
+		ID:       id,
+		UUID:     fakeUUID(id),
+		Name:     name,
+		owner:    owner,
+		accounts: make(map[int64]*Account),
+	}
+	s.orgUnits = append(s.orgUnits, ret)
+	return ret
+}
+
+func (s *Server) AddOrganizationalUnitAccount(orgUnit *OrganizationalUnit, account *Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orgUnit.accounts[account.ID] = account
+}
+
+func (s *Server) AddVaultRecord(name string, file string) *VaultRecord {
+	return s.AddGroupVaultRecord(nil, name, file)
+}
+
+func (s *Server) AddGroupVaultRecord(group *Group, name string, file string) *VaultRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addVaultRecord(group, name, file)
+}
+
+func (s *Server) addVaultRecord(group *Group, name string, file string) *VaultRecord {
+	id := s.newID()
+	ret := &VaultRecord{
+		ID:    id,
+		UUID:  fakeUUID(id),
+		Name:  name,
+		File:  file,
+		group: group,
+	}
+	s.vaultRecords = append(s.vaultRecords, ret)
+	return ret
+}
+
+// GroupByName returns the group with the given name, or nil when no such group exists.
+func (s *Server) GroupByName(name string) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.groups {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// GroupOnSystemByName returns the group on system with the given name on the system, or nil when
+// no such group on system exists.
+func (s *Server) GroupOnSystemByName(system *System, nameInSystem string) *GroupOnSystem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, gos := range s.groupsOnSystem {
+		if gos.system == system && gos.NameInSystem == nameInSystem {
+			return gos
+		}
+	}
+	return nil
+}
+
+// Rights returns the rights of the account in the group, or nil when the account is not a member.
+func (s *Server) Rights(group *Group, account *Account) *models.GroupGroupRights {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := group.members[account.ID]
+	if !ok {
+		return nil
+	}
+	rights := m.rights
+	return &rights
+}
+
+func (s *Server) HasVaultAccess(group *Group, account *Account) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := group.members[account.ID]
+	return ok && m.vaultAccess
+}
+
+// GroupClassification returns the classification of the group, or nil when it has none.
+func (s *Server) GroupClassification(group *Group) *GroupClassification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return group.classification
+}
+
+func (s *Server) AuthorizingGroup(subject *Group, authType models.RequestAuthorizingGroupType) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return subject.authorizing[authType]
+}
+
+// GroupOrganizationalUnit returns the organizational unit of the group, or nil when it has none.
+func (s *Server) GroupOrganizationalUnit(group *Group) *OrganizationalUnit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return group.orgUnit
+}
+
+func (s *Server) GroupOnSystemOwner(gos *GroupOnSystem) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return gos.owner
+}
+
+func (s *Server) SystemOwner(system *System) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return system.owner
+}
+
+func (s *Server) ClientOwner(client *Client) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return client.owner
+}
+
+func (s *Server) ServiceAccountAdmin(serviceAccount *ServiceAccount) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return serviceAccount.admin
+}
+
+// HasClientPermission returns whether the client holds the permission on the group or system.
+func (s *Server) HasClientPermission(client *Client, permissionType models.ClientOAuth2ClientPermissionType, group *Group, system *System) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range client.permissions {
+		if p.permissionType == permissionType && p.group == group && p.system == system {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProvisioned returns whether the group is one of the provisioned groups of the group on system.
+func (s *Server) IsProvisioned(gos *GroupOnSystem, group *Group) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range gos.provGroups {
+		if p.group == group {
+			return true
+		}
+	}
+	return false
+}
+
+// VaultRecords returns the names of the records in the vault of the group.
+func (s *Server) VaultRecords(group *Group) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]string, 0)
+	for _, record := range s.vaultRecords {
+		if record.group == group {
+			ret = append(ret, record.Name)
+		}
+	}
+	return ret
+}
+
+func (s *Server) InOrganizationalUnit(orgUnit *OrganizationalUnit, account *Account) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := orgUnit.accounts[account.ID]
+	return ok
+}
+
+// Requests returns all requests submitted to the server, including the requests created by the
+// server itself, in the order of submission.
+func (s *Server) Requests() []models.RequestModificationRequestable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]models.RequestModificationRequestable, len(s.requests))
+	for i, r := range s.requests {
+		ret[i] = r.model
+	}
+	return ret
+}
+
+func (s *Server) caller(r *http.Request) *Account {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+	for _, a := range s.accounts {
+		if a.token == token {
+			return a
+		}
+	}
+	return nil
+}
+
+// route returns the method and path of the request with all numeric path segments replaced by
+// {id}, together with the values of these segments.
+func route(r *http.Request) (string, []int64) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPath), "/"), "/")
+	ids := make([]int64, 0)
+	for i, segment := range segments {
+		id, err := strconv.ParseInt(segment, 10, 64)
+		if err == nil {
+			segments[i] = "{id}"
+			ids = append(ids, id)
+		}
+	}
+	return r.Method + " " + strings.Join(segments, "/"), ids
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(r.URL.Path, apiPath+"/") {
+		s.serveOAuth(w, r)
+		return
+	}
+	caller := s.caller(r)
+	if caller == nil {
+		s.writeError(w, http.StatusUnauthorized, "invalid or missing access token")
+		return
+	}
+
+	path, ids := route(r)
+	switch path {
+	case "GET account":
+		s.listAccounts(w, r)
+	case "GET account/me":
+		s.writeJSON(w, http.StatusOK, s.accountModel(caller, nil))
+	case "GET account/me/settings":
+		settings := models.NewAuthAccountSettings()
+		settings.SetKeyHubAdmin(&caller.KeyHubAdmin)
+		s.writeJSON(w, http.StatusOK, settings)
+	case "GET account/{id}":
+		s.getAccount(w, r, ids[0])
+	case "GET account/{id}/group":
+		s.listAccountGroups(w, r, ids[0])
+	case "GET client":
+		s.listClients(w, r)
+	case "GET client/{id}/permission":
+		s.listClientPermissions(w, ids[0])
+	case "DELETE client/{id}/permission/{id}":
+		s.deleteClientPermission(w, caller, ids[0], ids[1])
+	case "GET group":
+		s.listGroups(w, r)
+	case "POST group":
+		s.createGroups(w, r, caller)
+	case "GET group/{id}":
+		s.getGroup(w, r, ids[0])
+	case "PUT group/{id}":
+		s.updateGroup(w, r, caller, ids[0])
+	case "GET group/{id}/account":
+		s.listGroupAccounts(w, r, ids[0])
+	case "GET group/{id}/account/{id}":
+		s.getGroupAccount(w, ids[0], ids[1])
+	case "PUT group/{id}/account/{id}":
+		s.updateGroupAccount(w, r, caller, ids[0], ids[1])
+	case "DELETE group/{id}/account/{id}":
+		s.deleteGroupAccount(w, caller, ids[0], ids[1])
+	case "POST group/{id}/vault/recover":
+		s.recoverVault(w, r, caller, ids[0])
+	case "GET group/{id}/vault/record":
+		s.listGroupVaultRecords(w, r, caller, ids[0])
+	case "POST group/{id}/vault/record/{id}/move":
+		s.moveVaultRecord(w, r, caller, ids[0], ids[1])
+	case "GET groupclassification":
+		s.listGroupClassifications(w, r)
+	case "GET request":
+		s.listRequests(w, r)
+	case "POST request":
+		s.submitRequests(w, r, caller)
+	case "GET request/{id}":
+		s.getRequest(w, ids[0])
+	case "PUT request/{id}":
+		s.handleRequest(w, r, caller, ids[0])
+	case "GET serviceaccount":
+		s.listServiceAccounts(w, r)
+	case "GET system":
+		s.listSystems(w, r)
+	case "GET system/{id}/group":
+		s.listGroupsOnSystem(w, r, ids[0])
+	case "DELETE system/{id}/group/{id}":
+		s.deleteGroupOnSystem(w, caller, ids[0], ids[1])
+	case "GET system/{id}/group/{id}/provgroup":
+		s.listProvGroups(w, ids[0], ids[1])
+	case "DELETE system/{id}/group/{id}/provgroup/{id}":
+		s.deleteProvGroup(w, caller, ids[0], ids[1], ids[2])
+	case "GET organizationalunit":
+		s.listOrganizationalUnits(w, r)
+	case "GET organizationalunit/{id}/account":
+		s.listOrganizationalUnitAccounts(w, r, ids[0])
+	case "POST organizationalunit/{id}/account":
+		s.addOrganizationalUnitAccounts(w, r, caller, ids[0])
+	case "DELETE organizationalunit/{id}/account/{id}":
+		s.deleteOrganizationalUnitAccount(w, caller, ids[0], ids[1])
+	case "GET vaultrecord":
+		s.listVaultRecords(w, r)
+	default:
+		s.writeError(w, http.StatusNotFound, "%s is not supported by the fake KeyHub", path)
+	}
+}